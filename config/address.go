@@ -0,0 +1,81 @@
+package config
+
+import (
+	"fmt"
+	"strings"
+)
+
+//Address identifies config option independently of the config format. Fields is path of nested
+//fields inside structured option and can be used only with configs supporting structured values.
+type Address struct {
+	Section string
+	Option  string
+	Fields  []string
+}
+
+//ParseAddress parses address in form "section.option[.field[.field]]". Form "section/option"
+//used by INIConfig.GetOption is accepted as well.
+func ParseAddress(address string) (Address, error) {
+	var parts []string
+	if strings.Contains(address, "/") {
+		parts = strings.SplitN(address, "/", 2)
+	} else {
+		parts = strings.Split(address, ".")
+	}
+	if len(parts) < 2 || parts[0] == "" || parts[1] == "" {
+		return Address{}, fmt.Errorf("invalid option address '%s', expected 'section.option'", address)
+	}
+	return Address{Section: parts[0], Option: parts[1], Fields: parts[2:]}, nil
+}
+
+//String returns the address in form "section.option[.field[.field]]"
+func (addr Address) String() string {
+	return strings.Join(append([]string{addr.Section, addr.Option}, addr.Fields...), ".")
+}
+
+//Lookup returns option on given address in form "section.option[.field[.field]]" from any Config
+//implementation, so that callers don't need to know the concrete config type.
+func Lookup(conf Config, address string) (*Option, error) {
+	addr, err := ParseAddress(address)
+	if err != nil {
+		return nil, err
+	}
+	switch conf.(type) {
+	case *INIConfig, INIConfig:
+		if len(addr.Fields) > 0 {
+			return nil, fmt.Errorf("INI config does not support nested fields of option '%s'", addr)
+		}
+		return conf.GetOption(fmt.Sprintf("%s/%s", addr.Section, addr.Option))
+	default:
+		return conf.GetOption(addr.String())
+	}
+}
+
+//OptionMapping declares where single option is located in configs of different layouts. INI holds
+//address of the option in flat configs (INIConfig), Structured holds address of the option in configs
+//with nested values (JSON, YAML and TOML). When one of them is empty the other one is used.
+type OptionMapping struct {
+	INI        string
+	Structured string
+}
+
+//Mapping maps option names used by a component to their locations in config, so that the component
+//declares its options once and reads them from any Config implementation.
+type Mapping map[string]OptionMapping
+
+//Get returns option with given name from the config according to the mapping
+func (m Mapping) Get(conf Config, name string) (*Option, error) {
+	mapping, ok := m[name]
+	if !ok {
+		return nil, fmt.Errorf("option '%s' is not mapped", name)
+	}
+	address := mapping.Structured
+	switch conf.(type) {
+	case *INIConfig, INIConfig:
+		address = mapping.INI
+	}
+	if address == "" {
+		address = mapping.INI + mapping.Structured
+	}
+	return Lookup(conf, address)
+}
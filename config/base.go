@@ -1,10 +1,14 @@
 package config
 
 import (
+	"flag"
 	"fmt"
+	"os"
+	"path/filepath"
 	"reflect"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/infrawatch/apputils/logging"
 )
@@ -17,6 +21,19 @@ type Section struct {
 //Option holds single value of single configuration option.
 type Option struct {
 	value interface{}
+	// source is one of "default", "file", "env" or "flag"
+	source string
+	secret bool
+}
+
+//Source returns where the value of the option comes from: "default", "file", "env" or "flag".
+func (opt *Option) Source() string {
+	return opt.source
+}
+
+//Secret returns true if the option holds sensitive value, which should be redacted when printed.
+func (opt *Option) Secret() bool {
+	return opt.secret
 }
 
 //GetString returns option value as string.
@@ -51,6 +68,7 @@ func (opt *Option) GetInt() int64 {
 }
 
 //GetInts returns option value as slice of ints. Use this for value in form of <separator> separated list of ints.
+//Items which are not numbers are returned as 0, use Ints to get an error instead.
 func (opt *Option) GetInts(separator string) []int {
 	val := reflect.ValueOf(opt.value)
 	items := strings.Split(val.String(), separator)
@@ -77,12 +95,163 @@ func (opt *Option) GetStructured() interface{} {
 	return opt.value
 }
 
+//Int returns option value as int. String values are parsed, error is returned when the value cannot be converted.
+func (opt *Option) Int() (int64, error) {
+	return toInt64(opt.value)
+}
+
+//Float returns option value as float. String values are parsed, error is returned when the value cannot be converted.
+func (opt *Option) Float() (float64, error) {
+	return toFloat64(opt.value)
+}
+
+//Bool returns option value as bool. String values are parsed, error is returned when the value cannot be converted.
+func (opt *Option) Bool() (bool, error) {
+	return toBool(opt.value)
+}
+
+//Duration returns option value as duration. String values are parsed by time.ParseDuration (eg. "1m30s"),
+//numeric values are taken as nanoseconds.
+func (opt *Option) Duration() (time.Duration, error) {
+	return toDuration(opt.value)
+}
+
+//StringSlice returns option value as slice of strings. String values are split by comma and items are trimmed,
+//items of list values are converted to strings.
+func (opt *Option) StringSlice() ([]string, error) {
+	var output []string
+	if err := setValue(reflect.ValueOf(&output).Elem(), opt.value); err != nil {
+		return nil, err
+	}
+	return output, nil
+}
+
+//Ints returns option value as slice of ints. String values are split by comma and items are parsed,
+//error is returned when any of the items cannot be converted.
+func (opt *Option) Ints() ([]int64, error) {
+	var output []int64
+	if err := setValue(reflect.ValueOf(&output).Elem(), opt.value); err != nil {
+		return nil, err
+	}
+	return output, nil
+}
+
+//Map returns option value as map. String values are parsed from comma separated list of key=value pairs
+//(eg. "a=1,b=2"), values of structured formats have to be objects.
+func (opt *Option) Map() (map[string]interface{}, error) {
+	if str, ok := opt.value.(string); ok {
+		output := make(map[string]interface{})
+		if strings.TrimSpace(str) == "" {
+			return output, nil
+		}
+		for _, item := range strings.Split(str, ",") {
+			pair := strings.SplitN(item, "=", 2)
+			if len(pair) != 2 || strings.TrimSpace(pair[0]) == "" {
+				return nil, fmt.Errorf("value (%v) is not list of key=value pairs", str)
+			}
+			output[strings.TrimSpace(pair[0])] = strings.TrimSpace(pair[1])
+		}
+		return output, nil
+	}
+	var output map[string]interface{}
+	if err := setValue(reflect.ValueOf(&output).Elem(), opt.value); err != nil {
+		return nil, err
+	}
+	return output, nil
+}
+
+//MustInt returns option value as int and panics when the value cannot be converted.
+func (opt *Option) MustInt() int64 {
+	value, err := opt.Int()
+	if err != nil {
+		panic(err)
+	}
+	return value
+}
+
+//MustFloat returns option value as float and panics when the value cannot be converted.
+func (opt *Option) MustFloat() float64 {
+	value, err := opt.Float()
+	if err != nil {
+		panic(err)
+	}
+	return value
+}
+
+//MustBool returns option value as bool and panics when the value cannot be converted.
+func (opt *Option) MustBool() bool {
+	value, err := opt.Bool()
+	if err != nil {
+		panic(err)
+	}
+	return value
+}
+
+//MustDuration returns option value as duration and panics when the value cannot be converted.
+func (opt *Option) MustDuration() time.Duration {
+	value, err := opt.Duration()
+	if err != nil {
+		panic(err)
+	}
+	return value
+}
+
+//MustStringSlice returns option value as slice of strings and panics when the value cannot be converted.
+func (opt *Option) MustStringSlice() []string {
+	value, err := opt.StringSlice()
+	if err != nil {
+		panic(err)
+	}
+	return value
+}
+
+//MustInts returns option value as slice of ints and panics when the value cannot be converted.
+func (opt *Option) MustInts() []int64 {
+	value, err := opt.Ints()
+	if err != nil {
+		panic(err)
+	}
+	return value
+}
+
+//MustMap returns option value as map and panics when the value cannot be converted.
+func (opt *Option) MustMap() map[string]interface{} {
+	value, err := opt.Map()
+	if err != nil {
+		panic(err)
+	}
+	return value
+}
+
 //Config interface for methods to accept various types of config objects (INI/JSON/...)
 type Config interface {
 	Parse(path string) error
 	GetOption(name string) (*Option, error)
 }
 
+//StructuredConfig is implemented by config objects supporting parameters structured deeper than section/parameter
+type StructuredConfig interface {
+	Config
+	AddStructured(section, name, tag string, object interface{})
+}
+
+//NewConfigForFile creates config object for the format given by extension of the file: ".ini" and ".conf"
+//for INI, ".json" for JSON, ".yaml" and ".yml" for YAML and ".toml" for TOML.
+func NewConfigForFile(path string, metadata map[string][]Parameter, logger *logging.Logger) (Config, error) {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".ini", ".conf":
+		return NewINIConfig(metadata, logger), nil
+	case ".json":
+		return NewJSONConfig(metadata, logger), nil
+	case ".yaml", ".yml":
+		return NewYAMLConfig(metadata, logger), nil
+	case ".toml":
+		return NewTOMLConfig(metadata, logger), nil
+	default:
+		return nil, fmt.Errorf("unable to detect config format of file %s", path)
+	}
+}
+
 //Validator checks the validity of the config option value. It accepts single value
 //and returns nil (and corrected value if possible) if the value is valid
 //or appropriate error otherwise.
@@ -94,13 +263,140 @@ type Parameter struct {
 	Tag        string
 	Default    interface{}
 	Validators []Validator
+	// Description is used in help text of command-line flags
+	Description string
+	// Required parameters have to be set in config file, environment or command line, default is not used
+	Required bool
+	// Deprecated holds hint (eg. name of the replacement) logged as warning when the parameter is set,
+	// empty string means the parameter is not deprecated
+	Deprecated string
+	// Secret parameters (eg. passwords) are redacted in logs, dumps and diffs
+	Secret bool
 }
 
 //WithConfigBase holds config metadata and logger
 type WithConfigBase struct {
-	log      *logging.Logger
-	metadata map[string][]Parameter
-	Sections map[string]*Section
+	log        *logging.Logger
+	metadata   map[string][]Parameter
+	envPrefix  string
+	envEnabled bool
+	flags      *flag.FlagSet
+	// raw holds all values parsed from config file per section, including values without metadata
+	raw               map[string]map[string]interface{}
+	sectionValidators map[string][]SectionValidator
+	strict            StrictMode
+	Sections          map[string]*Section
+}
+
+func newConfigBase(metadata map[string][]Parameter, logger *logging.Logger) WithConfigBase {
+	return WithConfigBase{
+		log:      logger,
+		metadata: metadata,
+		raw:      make(map[string]map[string]interface{}),
+		Sections: make(map[string]*Section),
+	}
+}
+
+func (base *WithConfigBase) resetRaw() {
+	for section := range base.raw {
+		delete(base.raw, section)
+	}
+}
+
+//SetEnvPrefix enables overriding of config values by environment variables. Variable name is composed
+//of given prefix, section name and parameter name joined by underscore and upper-cased, eg. value
+//of parameter "connection" in section "amqp1" with prefix "apputils" is overridden by variable
+//APPUTILS_AMQP1_CONNECTION. Values from environment take precedence over values from file and pass
+//through the same validators.
+func (base *WithConfigBase) SetEnvPrefix(prefix string) {
+	base.envPrefix = prefix
+	base.envEnabled = true
+}
+
+//EnvVarName returns name of environment variable overriding given parameter, all characters
+//other than letters and digits are replaced by underscore.
+func EnvVarName(prefix, section, name string) string {
+	parts := []string{}
+	for _, part := range []string{prefix, section, name} {
+		if part != "" {
+			parts = append(parts, part)
+		}
+	}
+	return strings.Map(func(r rune) rune {
+		if (r >= 'A' && r <= 'Z') || (r >= '0' && r <= '9') {
+			return r
+		}
+		return '_'
+	}, strings.ToUpper(strings.Join(parts, "_")))
+}
+
+//FlagName returns name of command-line flag overriding given parameter
+func FlagName(section, name string) string {
+	return fmt.Sprintf("%s.%s", section, name)
+}
+
+//override holds value of parameter given by environment variable or command-line flag
+type override struct {
+	// source is "env" or "flag"
+	source string
+	// origin describes where the value comes from for error messages
+	origin string
+	value  string
+}
+
+func (ovr override) wrapError(err error) error {
+	return fmt.Errorf("%s (set by %s)", err, ovr.origin)
+}
+
+//lookupOverride returns value overriding the one from config file, command-line flags take precedence
+//over environment variables
+func (base *WithConfigBase) lookupOverride(section, name string) (override, bool) {
+	if base.flags != nil {
+		var ovr override
+		found := false
+		flagName := FlagName(section, name)
+		base.flags.Visit(func(f *flag.Flag) {
+			if f.Name == flagName {
+				ovr = override{source: "flag", origin: "flag -" + flagName, value: f.Value.String()}
+				found = true
+			}
+		})
+		if found {
+			return ovr, true
+		}
+	}
+	if base.envEnabled {
+		variable := EnvVarName(base.envPrefix, section, name)
+		if value, ok := os.LookupEnv(variable); ok {
+			return override{source: "env", origin: "environment variable " + variable, value: value}, true
+		}
+	}
+	return override{}, false
+}
+
+//bindFlags defines flag for every parameter, name returns name of the parameter in config file
+func (base *WithConfigBase) bindFlags(flags *flag.FlagSet, name func(Parameter) string) {
+	base.flags = flags
+	for section, params := range base.metadata {
+		for _, param := range params {
+			def := ""
+			if param.Default != nil {
+				def = fmt.Sprintf("%v", param.Default)
+			}
+			base.defineFlag(section, name(param), def, param.Description)
+		}
+	}
+}
+
+func (base *WithConfigBase) defineFlag(section, name, def, description string) {
+	usage := description
+	if usage == "" {
+		usage = fmt.Sprintf("value of parameter %s in section %s", name, section)
+	}
+	if base.envEnabled {
+		usage = fmt.Sprintf("%s (env %s)", usage, EnvVarName(base.envPrefix, section, name))
+	}
+	base.flags.String(FlagName(section, name), def, usage)
 }
 
 //GetMetadata returns config metadata
@@ -124,20 +420,28 @@ func validate(value interface{}, validators []Validator) (interface{}, error) {
 	return val, nil
 }
 
-func createOption(value interface{}, metadata Parameter, log *logging.Logger) (*Option, error) {
-	option := &Option{}
+//createOption validates value of the parameter coming from given source ("parsed" for config file,
+//"env" for environment) and creates Option for it. Nil value is replaced by the parameter default.
+func createOption(value interface{}, source string, metadata Parameter, log *logging.Logger) (*Option, error) {
+	option := &Option{source: source, secret: metadata.Secret}
+	if source == "parsed" {
+		option.source = "file"
+	}
 	val := reflect.ValueOf(value)
-	result := ""
+	result := source
 	if !val.IsValid() {
 		value = metadata.Default
 		result = "default"
-	} else {
-		result = "parsed"
+		option.source = result
 	}
 	value, err := validate(value, metadata.Validators)
-	log.Metadata(map[string]interface{}{
+	printed := fmt.Sprintf("%v", value)
+	if metadata.Secret {
+		printed = redacted
+	}
+	log = log.With(logging.Metadata{
 		"parameter": metadata.Name,
-		"value":     fmt.Sprintf("%v", value),
+		"value":     printed,
 		"result":    result,
 	})
 	if err == nil {
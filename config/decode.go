@@ -0,0 +1,322 @@
+package config
+
+import (
+	"fmt"
+	"math"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var durationType = reflect.TypeOf(time.Duration(0))
+
+//Decoder is implemented by config objects which can fill user structs with parsed values
+type Decoder interface {
+	Config
+	Decode(section string, target interface{}) error
+	Unmarshal(target interface{}) error
+}
+
+//Decode fills fields of struct pointed to by target with values of given section of the parsed file.
+//Name of the option in the file is given by `config` tag (field name is used when the tag is missing,
+//"-" skips the field), `default` tag holds value used when the option is missing and `validate` tag holds
+//specification of validators (see ParseValidators) through which the value passes. Values from environment
+//and command-line flags override the file the same way as for Parse. Values are converted to field types,
+//string values are parsed, so eg. "5s" fills time.Duration field and "a,b" fills []string field. Nested
+//struct fields are decoded recursively from nested values of structured formats.
+func (base *WithConfigBase) Decode(section string, target interface{}) error {
+	value := reflect.ValueOf(target)
+	if value.Kind() != reflect.Ptr || value.Elem().Kind() != reflect.Struct {
+		return fmt.Errorf("decode target has to be pointer to struct, got %T", target)
+	}
+	return decodeStruct(base.overrideLookup(section), section, base.raw[section], value.Elem())
+}
+
+//overrideLookup returns function looking up overrides of options in given section
+func (base *WithConfigBase) overrideLookup(section string) func(string) (override, bool) {
+	return func(name string) (override, bool) {
+		return base.lookupOverride(section, name)
+	}
+}
+
+//Unmarshal fills struct pointed to by target with whole parsed file. Every field of the target has to be
+//struct and is filled by Decode with section given by `config` tag or by the field name.
+func (base *WithConfigBase) Unmarshal(target interface{}) error {
+	value := reflect.ValueOf(target)
+	if value.Kind() != reflect.Ptr || value.Elem().Kind() != reflect.Struct {
+		return fmt.Errorf("unmarshal target has to be pointer to struct, got %T", target)
+	}
+	value = value.Elem()
+	for i := 0; i < value.NumField(); i++ {
+		field := value.Type().Field(i)
+		section := fieldName(field)
+		if field.PkgPath != "" || section == "" {
+			continue
+		}
+		if field.Type.Kind() != reflect.Struct {
+			return fmt.Errorf("field %s for section %s has to be struct", field.Name, section)
+		}
+		if err := decodeStruct(base.overrideLookup(section), section, base.raw[section], value.Field(i)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+//fieldName returns name of the option for struct field or empty string if the field should be skipped.
+//Name is taken from `config` tag, tags of structured formats are used when it is missing.
+func fieldName(field reflect.StructField) string {
+	for _, key := range []string{"config", "json", "yaml", "toml"} {
+		switch name := strings.Split(field.Tag.Get(key), ",")[0]; name {
+		case "-":
+			return ""
+		case "":
+			continue
+		default:
+			return name
+		}
+	}
+	return field.Name
+}
+
+//decodeStruct fills target with values, path is used in error messages. Overrides from environment
+//and flags are looked up by given function, which is nil for nested structs.
+func decodeStruct(lookup func(string) (override, bool), path string, values map[string]interface{}, target reflect.Value) error {
+	for i := 0; i < target.NumField(); i++ {
+		field := target.Type().Field(i)
+		name := fieldName(field)
+		if field.PkgPath != "" || name == "" {
+			continue
+		}
+		fieldPath := name
+		if path != "" {
+			fieldPath = fmt.Sprintf("%s.%s", path, name)
+		}
+
+		value, ok := values[name]
+		var ovr override
+		overridden := false
+		if lookup != nil {
+			if ovr, overridden = lookup(name); overridden {
+				value, ok = ovr.value, true
+			}
+		}
+
+		if field.Type.Kind() == reflect.Struct && !overridden {
+			nested, isMap := value.(map[string]interface{})
+			if ok && !isMap {
+				return fmt.Errorf("failed to decode parameter %s: value (%v) is not structured", fieldPath, value)
+			}
+			if err := decodeStruct(nil, fieldPath, nested, target.Field(i)); err != nil {
+				return err
+			}
+			continue
+		}
+
+		if !ok {
+			if value, ok = field.Tag.Lookup("default"); !ok {
+				continue
+			}
+		}
+		validators, err := ParseValidators(field.Tag.Get("validate"))
+		if err != nil {
+			return fmt.Errorf("failed to decode parameter %s: %s", fieldPath, err)
+		}
+		if value, err = validate(value, validators); err != nil {
+			err = fmt.Errorf("failed validation for parameter %s: %s", fieldPath, err)
+		} else if err = setValue(target.Field(i), value); err != nil {
+			err = fmt.Errorf("failed to decode parameter %s: %s", fieldPath, err)
+		}
+		if err != nil {
+			if overridden {
+				err = ovr.wrapError(err)
+			}
+			return err
+		}
+	}
+	return nil
+}
+
+//setValue stores value to target converting it to the target type if needed
+func setValue(target reflect.Value, value interface{}) error {
+	if value == nil {
+		return nil
+	}
+	val := reflect.ValueOf(value)
+	if target.Type() == durationType {
+		duration, err := toDuration(value)
+		if err != nil {
+			return err
+		}
+		target.SetInt(int64(duration))
+		return nil
+	}
+	if val.Type().AssignableTo(target.Type()) {
+		target.Set(val)
+		return nil
+	}
+
+	switch target.Kind() {
+	case reflect.String:
+		switch val.Kind() {
+		case reflect.String, reflect.Bool, reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+			reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Float32, reflect.Float64:
+			target.SetString(fmt.Sprintf("%v", value))
+			return nil
+		}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		number, err := toInt64(value)
+		if err != nil {
+			return err
+		}
+		if target.OverflowInt(number) {
+			return fmt.Errorf("value (%v) overflows %s", value, target.Type())
+		}
+		target.SetInt(number)
+		return nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		number, err := toInt64(value)
+		if err != nil {
+			return err
+		}
+		if number < 0 || target.OverflowUint(uint64(number)) {
+			return fmt.Errorf("value (%v) overflows %s", value, target.Type())
+		}
+		target.SetUint(uint64(number))
+		return nil
+	case reflect.Float32, reflect.Float64:
+		number, err := toFloat64(value)
+		if err != nil {
+			return err
+		}
+		target.SetFloat(number)
+		return nil
+	case reflect.Bool:
+		boolean, err := toBool(value)
+		if err != nil {
+			return err
+		}
+		target.SetBool(boolean)
+		return nil
+	case reflect.Slice:
+		items := val
+		if str, ok := value.(string); ok {
+			items = reflect.ValueOf(strings.Split(str, ","))
+		}
+		if items.Kind() == reflect.Slice || items.Kind() == reflect.Array {
+			slice := reflect.MakeSlice(target.Type(), items.Len(), items.Len())
+			for i := 0; i < items.Len(); i++ {
+				item := items.Index(i).Interface()
+				if str, ok := item.(string); ok {
+					item = strings.TrimSpace(str)
+				}
+				if err := setValue(slice.Index(i), item); err != nil {
+					return fmt.Errorf("item %d: %s", i, err)
+				}
+			}
+			target.Set(slice)
+			return nil
+		}
+	case reflect.Map:
+		if val.Kind() == reflect.Map && target.Type().Key().Kind() == reflect.String {
+			output := reflect.MakeMap(target.Type())
+			for _, key := range val.MapKeys() {
+				item := reflect.New(target.Type().Elem()).Elem()
+				if err := setValue(item, val.MapIndex(key).Interface()); err != nil {
+					return fmt.Errorf("key %v: %s", key, err)
+				}
+				output.SetMapIndex(reflect.ValueOf(fmt.Sprintf("%v", key)).Convert(target.Type().Key()), item)
+			}
+			target.Set(output)
+			return nil
+		}
+	case reflect.Struct:
+		if values, ok := value.(map[string]interface{}); ok {
+			return decodeStruct(nil, "", values, target)
+		}
+	case reflect.Ptr:
+		item := reflect.New(target.Type().Elem())
+		if err := setValue(item.Elem(), value); err != nil {
+			return err
+		}
+		target.Set(item)
+		return nil
+	}
+	return fmt.Errorf("cannot convert value (%v) of type %T to %s", value, value, target.Type())
+}
+
+func toInt64(value interface{}) (int64, error) {
+	val := reflect.ValueOf(value)
+	switch val.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return val.Int(), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		if val.Uint() > math.MaxInt64 {
+			return 0, fmt.Errorf("value (%v) is out of range of int", value)
+		}
+		return int64(val.Uint()), nil
+	case reflect.Float32, reflect.Float64:
+		if val.Float() != math.Trunc(val.Float()) {
+			return 0, fmt.Errorf("value (%v) is not int", value)
+		}
+		return int64(val.Float()), nil
+	case reflect.String:
+		number, err := strconv.ParseInt(strings.TrimSpace(val.String()), 10, 64)
+		if err != nil {
+			return 0, fmt.Errorf("value (%v) is not int", value)
+		}
+		return number, nil
+	}
+	return 0, fmt.Errorf("value (%v) is not int", value)
+}
+
+func toFloat64(value interface{}) (float64, error) {
+	val := reflect.ValueOf(value)
+	switch val.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return float64(val.Int()), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return float64(val.Uint()), nil
+	case reflect.Float32, reflect.Float64:
+		return val.Float(), nil
+	case reflect.String:
+		number, err := strconv.ParseFloat(strings.TrimSpace(val.String()), 64)
+		if err != nil {
+			return 0, fmt.Errorf("value (%v) is not float", value)
+		}
+		return number, nil
+	}
+	return 0, fmt.Errorf("value (%v) is not float", value)
+}
+
+func toBool(value interface{}) (bool, error) {
+	val := reflect.ValueOf(value)
+	switch val.Kind() {
+	case reflect.Bool:
+		return val.Bool(), nil
+	case reflect.String:
+		boolean, err := strconv.ParseBool(strings.TrimSpace(val.String()))
+		if err != nil {
+			return false, fmt.Errorf("value (%v) is not bool", value)
+		}
+		return boolean, nil
+	}
+	return false, fmt.Errorf("value (%v) is not bool", value)
+}
+
+//toDuration converts string in format accepted by time.ParseDuration or number of nanoseconds to duration
+func toDuration(value interface{}) (time.Duration, error) {
+	if str, ok := value.(string); ok {
+		duration, err := time.ParseDuration(strings.TrimSpace(str))
+		if err != nil {
+			return 0, fmt.Errorf("value (%v) is not duration", value)
+		}
+		return duration, nil
+	}
+	number, err := toInt64(value)
+	if err != nil {
+		return 0, fmt.Errorf("value (%v) is not duration", value)
+	}
+	return time.Duration(number), nil
+}
@@ -0,0 +1,375 @@
+package config
+
+import (
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/infrawatch/apputils/logging"
+	"github.com/infrawatch/apputils/misc"
+)
+
+//documentConfig implements config saved in format describing tree of values (JSON, YAML, TOML),
+//which is parsed by given unmarshal function. Parameter names in the file are taken from struct tags
+//with key of the format, falling back to json tags and to parameter names.
+type documentConfig struct {
+	WithConfigBase
+	format     string
+	tagKey     string
+	unmarshal  func([]byte, interface{}) error
+	marshal    func(interface{}) ([]byte, error)
+	structured map[string][]reflect.StructField
+}
+
+func newDocumentConfig(metadata map[string][]Parameter, logger *logging.Logger, format, tagKey string, unmarshal func([]byte, interface{}) error, marshal func(interface{}) ([]byte, error)) documentConfig {
+	return documentConfig{
+		WithConfigBase: newConfigBase(metadata, logger),
+		format:         format,
+		tagKey:         tagKey,
+		unmarshal:      unmarshal,
+		marshal:        marshal,
+		structured:     make(map[string][]reflect.StructField),
+	}
+}
+
+//AddStructured can be used when config values are structured deeper than section/parameter. Tag of the parameter
+//and tags of fields of the object (nested on any level) can contain `default` tag with value used when the field
+//is missing in config file, `required:"true"` for fields which have to be present when their parent is present
+//and `validate` tag with specification of validators (see ParseValidators, use RegisterValidator for custom
+//validation functions). Validation errors contain full path of the field, eg. Amqp1.Connection.Address.
+//Parameters and fields tagged `secret:"true"` are redacted in Dump output and in Change descriptions.
+func (conf *documentConfig) AddStructured(section, name, tag string, object interface{}) {
+	if _, ok := conf.structured[section]; !ok {
+		conf.structured[section] = make([]reflect.StructField, 0)
+	}
+	conf.structured[section] = append(conf.structured[section],
+		reflect.StructField{
+			Name: name,
+			Type: reflect.TypeOf(object),
+			Tag:  reflect.StructTag(tag),
+		},
+	)
+}
+
+//BindFlags defines command-line flag named "<section>.<name>" for every parameter in the metadata
+//and every structured parameter added so far, name is the one used in config file. Structured parameters
+//take value in the config file format. Values of flags set on command line take precedence over environment
+//and config file when Parse is called after the flags are parsed. Call SetEnvPrefix first to include names
+//of environment variables in the help text.
+func (conf *documentConfig) BindFlags(flags *flag.FlagSet) {
+	conf.bindFlags(flags, func(param Parameter) string { return conf.paramName(param.Name, param.Tag) })
+	for section, params := range conf.structured {
+		for _, param := range params {
+			name := conf.fieldName(param.Name, string(param.Tag))
+			conf.defineFlag(section, name, "", fmt.Sprintf("%s value of structured parameter %s in section %s", conf.format, name, section))
+		}
+	}
+}
+
+//ParseBytes loads data from given byte slice
+func (conf documentConfig) ParseBytes(data []byte) error {
+	// parse flat parameters
+	var flat map[string]interface{}
+	if err := conf.unmarshal(data, &flat); err != nil {
+		conf.log.Error("unable to parse data from provided configuration file", logging.Metadata{
+			"error": err,
+		})
+		return err
+	}
+	conf.resetRaw()
+	for section, values := range flat {
+		if sectMap, ok := values.(map[string]interface{}); ok {
+			conf.raw[section] = sectMap
+		}
+	}
+
+	var unknown []error
+	if conf.strict != StrictOff {
+		unknown = conf.unknownValues(flat)
+	}
+	errs := conf.checkUnknown(conf.knownNames(), unknown...)
+	for _, section := range sectionNames(conf.metadata) {
+		conf.Sections[section] = &Section{Options: make(map[string]*Option)}
+		sectMap, _ := flat[section].(map[string]interface{})
+		for _, param := range conf.metadata[section] {
+			tag := conf.paramName(param.Name, param.Tag)
+			def := param.Default
+			opt, err := conf.loadOption(section, tag, param, sectMap[tag], func(value string) interface{} { return conf.overrideValue(value, def) })
+			if err != nil {
+				errs = append(errs, err)
+				continue
+			}
+			conf.Sections[section].Options[param.Name] = opt
+		}
+	}
+
+	// parse structured parameters
+	// field names have to be exported, so sections are named by index and matched by tags
+	structuredSections := make([]string, 0, len(conf.structured))
+	for sect := range conf.structured {
+		structuredSections = append(structuredSections, sect)
+	}
+	sort.Strings(structuredSections)
+	sections := []reflect.StructField{}
+	for i, sect := range structuredSections {
+		sections = append(sections,
+			reflect.StructField{
+				Name: fmt.Sprintf("Section%d", i),
+				Type: reflect.StructOf(conf.structured[sect]),
+				Tag:  reflect.StructTag(fmt.Sprintf(`%s:"%s"`, conf.tagKey, sect)),
+			},
+		)
+	}
+	parsed := reflect.New(reflect.StructOf(sections)).Interface()
+
+	if err := conf.unmarshal(data, parsed); err != nil {
+		conf.log.Error("unable to parse data from provided configuration file", logging.Metadata{
+			"error": err,
+		})
+		return err
+	}
+
+	parsedSections := reflect.ValueOf(parsed).Elem()
+	for i, section := range structuredSections {
+		params := conf.structured[section]
+		if _, ok := conf.Sections[section]; !ok {
+			conf.Sections[section] = &Section{Options: make(map[string]*Option)}
+		}
+		sect := parsedSections.Field(i)
+		sectValue, _ := conf.lookupKey(flat, section)
+		sectTree, _ := sectValue.(map[string]interface{})
+		for _, param := range params {
+			value := sect.FieldByName(param.Name)
+			name := conf.fieldName(param.Name, string(param.Tag))
+			tree, present := conf.lookupKey(sectTree, name)
+			ovr, overridden := conf.lookupOverride(section, name)
+			if overridden {
+				parsedValue, err := conf.unmarshalValue(ovr.value, param)
+				if err != nil {
+					errs = append(errs, ovr.wrapError(fmt.Errorf("failed to parse parameter %s: %s", param.Name, err)))
+					continue
+				}
+				value = reflect.New(param.Type).Elem()
+				value.Set(reflect.ValueOf(parsedValue))
+				tree, _ = conf.unmarshalValue(ovr.value, reflect.StructField{Name: param.Name, Type: reflect.TypeOf((*interface{})(nil)).Elem()})
+				present = true
+			}
+			path := fmt.Sprintf("%s.%s", section, param.Name)
+			if paramErrs := conf.validateStructured(path, param, value, tree, present, true); len(paramErrs) > 0 {
+				for _, err := range paramErrs {
+					if overridden {
+						err = ovr.wrapError(err)
+					}
+					errs = append(errs, err)
+				}
+				continue
+			}
+			source := "default"
+			if overridden {
+				source = ovr.source
+			} else if present {
+				source = "file"
+			}
+			conf.Sections[section].Options[param.Name] = &Option{
+				value:  value.Interface(),
+				source: source,
+				secret: param.Tag.Get("secret") == "true",
+			}
+		}
+	}
+	errs = append(errs, conf.validateSections()...)
+	return errs.errorOrNil()
+}
+
+//knownNames returns names of all parameters in config file per section, including structured parameters
+func (conf documentConfig) knownNames() map[string][]string {
+	known := make(map[string][]string)
+	for section, params := range conf.metadata {
+		for _, param := range params {
+			known[section] = append(known[section], conf.paramName(param.Name, param.Tag))
+		}
+	}
+	for section, params := range conf.structured {
+		for _, param := range params {
+			known[section] = append(known[section], conf.fieldName(param.Name, string(param.Tag)))
+		}
+	}
+	return known
+}
+
+//paramName returns name under which the flat parameter is stored in config file, json tag is used
+//when tag of the format is missing
+func (conf documentConfig) paramName(name, tag string) string {
+	for _, key := range []string{conf.tagKey, "json"} {
+		if tagName := strings.Split(reflect.StructTag(tag).Get(key), ",")[0]; tagName != "" && tagName != "-" {
+			return tagName
+		}
+	}
+	return name
+}
+
+//fieldName returns key of the structured parameter or of its field in config file resolved the same way
+//the decoder of the format resolves it, ie. from tag of the format, falling back to the lowercased field name
+//for YAML and to the field name for other formats
+func (conf documentConfig) fieldName(name, tag string) string {
+	if tagName := strings.Split(reflect.StructTag(tag).Get(conf.tagKey), ",")[0]; tagName != "" && tagName != "-" {
+		return tagName
+	}
+	if conf.tagKey == "yaml" {
+		return strings.ToLower(name)
+	}
+	return name
+}
+
+//lookupKey returns value stored under given key in the generic form of parsed document. Keys are matched
+//the same way the decoder of the format matches them to struct fields, ie. case-insensitively for JSON
+//and TOML when there is no exact match.
+func (conf documentConfig) lookupKey(values map[string]interface{}, key string) (interface{}, bool) {
+	if value, ok := values[key]; ok {
+		return value, true
+	}
+	if conf.tagKey != "json" && conf.tagKey != "toml" {
+		return nil, false
+	}
+	for name, value := range values {
+		if strings.EqualFold(name, key) {
+			return value, true
+		}
+	}
+	return nil, false
+}
+
+//unmarshalValue decodes value of structured parameter given in the config file format
+func (conf documentConfig) unmarshalValue(value string, param reflect.StructField) (interface{}, error) {
+	// wrap the value to document, so that formats which don't allow bare values can be decoded too
+	wrapper := reflect.New(reflect.StructOf([]reflect.StructField{{
+		Name: "Value",
+		Type: param.Type,
+		Tag:  reflect.StructTag(fmt.Sprintf(`%s:"value"`, conf.tagKey)),
+	}}))
+	var document string
+	switch conf.tagKey {
+	case "toml":
+		document = fmt.Sprintf("value = %s", value)
+	default:
+		document = fmt.Sprintf(`{"value": %s}`, value)
+	}
+	if err := conf.unmarshal([]byte(document), wrapper.Interface()); err != nil {
+		return nil, err
+	}
+	return wrapper.Elem().Field(0).Interface(), nil
+}
+
+//overrideValue converts value from environment or command line to the type decoder of the config file
+//would produce for the parameter. Values of non-string parameters are decoded in the config file format,
+//eg. "5" to number or "true" to bool, values which fail to decode are kept as strings.
+func (conf documentConfig) overrideValue(value string, def interface{}) interface{} {
+	if _, ok := def.(string); ok || def == nil {
+		return value
+	}
+	decoded, err := conf.unmarshalValue(value, reflect.StructField{Name: "Value", Type: reflect.TypeOf((*interface{})(nil)).Elem()})
+	if err != nil || decoded == nil {
+		return value
+	}
+	return decoded
+}
+
+//Parse loads data from given file. Path can be also directory, in which case all files with extension
+//of the format in it are loaded in lexical order and deep merged, so that later files override only values
+//they contain. Files can include other files or directories by IncludeKey directive.
+func (conf documentConfig) Parse(path string) error {
+	contents := make(map[string][]byte)
+	documents := make(map[string]map[string]interface{})
+	files, err := resolveFiles(path, formatExtensions[conf.format], func(file string) ([]string, error) {
+		data, document, patterns, err := conf.readDocument(file)
+		if err != nil {
+			conf.log.Error("unable to load provided configuration file", logging.Metadata{
+				"error": err,
+				"path":  file,
+			})
+			return nil, err
+		}
+		contents[file] = data
+		documents[file] = document
+		return patterns, nil
+	})
+	if err != nil {
+		return err
+	}
+
+	switch {
+	case len(files) == 0:
+		return fmt.Errorf("no config files found in %s", path)
+	case len(files) == 1 && files[0] == path:
+		return conf.ParseBytes(contents[path])
+	}
+	merged := make([]map[string]interface{}, len(files))
+	for i, file := range files {
+		merged[i] = documents[file]
+	}
+	data, err := conf.marshal(misc.DeepMergeMaps(merged...))
+	if err != nil {
+		return fmt.Errorf("failed to merge config files: %s", err)
+	}
+	return conf.ParseBytes(data)
+}
+
+//readDocument reads and decodes single config file. Returns content of the file, the decoded document
+//without include directive and patterns of included files.
+func (conf documentConfig) readDocument(file string) ([]byte, map[string]interface{}, []string, error) {
+	data, err := ioutil.ReadFile(file)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	var document map[string]interface{}
+	if err := conf.unmarshal(data, &document); err != nil {
+		return nil, nil, nil, err
+	}
+	patterns, err := includePatterns(document[IncludeKey], file)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	delete(document, IncludeKey)
+	return data, document, patterns, nil
+}
+
+func extractValue(opt *Option, optAddr []string) (*Option, error) {
+	var option *Option
+	var err error
+
+	theType := reflect.TypeOf(opt.GetStructured())
+	if _, ok := theType.FieldByName(optAddr[0]); ok {
+		value := reflect.ValueOf(opt.GetStructured()).FieldByName(optAddr[0])
+		option = &Option{value: value.Interface()}
+		if len(optAddr) > 1 {
+			option, err = extractValue(option, optAddr[1:])
+		}
+	}
+	return option, err
+}
+
+//GetOption returns Option objects according to given "section.option[.sub-option[.sub-option]]" string.
+func (conf documentConfig) GetOption(name string) (*Option, error) {
+	var option *Option
+	var err error
+
+	nameparts := strings.SplitN(name, ".", 2)
+	if section, ok := conf.Sections[nameparts[0]]; ok {
+		optAddr := strings.Split(nameparts[1], ".")
+		if opt, ok := section.Options[optAddr[0]]; ok {
+			if len(optAddr) == 1 {
+				option = opt
+			} else {
+				option, err = extractValue(opt, optAddr[1:])
+			}
+		} else {
+			err = fmt.Errorf("did not find option '%s' in section '%s'", nameparts[1], nameparts[0])
+		}
+	} else {
+		err = fmt.Errorf("did not find section '%s'", nameparts[0])
+	}
+	return option, err
+}
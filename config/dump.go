@@ -0,0 +1,319 @@
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"reflect"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+const redacted = "<redacted>"
+
+//Dumper is implemented by config objects which can render the effective config, eg. for --print-config
+type Dumper interface {
+	Config
+	Dump(w io.Writer, opts DumpOptions) error
+}
+
+//DumpOptions configures rendering of the effective config by Dump
+type DumpOptions struct {
+	// Format is one of "ini", "json" or "yaml"
+	Format string
+	// Sources adds source of each value (default, file, env or flag), as comments for INI and YAML
+	// and as {"value": ..., "source": ...} objects for JSON
+	Sources bool
+	// ShowSecrets disables redaction of values of secret parameters
+	ShowSecrets bool
+}
+
+//dumpEntry holds single option of the effective config with the name used in config file
+type dumpEntry struct {
+	section string
+	name    string
+	option  *Option
+}
+
+//Dump renders effective config (values after defaults, overrides and validation) in given format.
+//Values of secret parameters are redacted unless ShowSecrets is set.
+func (conf *INIConfig) Dump(w io.Writer, opts DumpOptions) error {
+	entries := []dumpEntry{}
+	for _, section := range sectionNames(conf.metadata) {
+		for _, param := range conf.metadata[section] {
+			entries = conf.appendEntry(entries, section, param.Name, param.Name)
+		}
+	}
+	return dump(w, entries, opts)
+}
+
+//Dump renders effective config (values after defaults, overrides and validation) in given format
+//including structured parameters. Values of secret parameters and fields of structured parameters tagged
+//`secret:"true"` are redacted unless ShowSecrets is set.
+func (conf *documentConfig) Dump(w io.Writer, opts DumpOptions) error {
+	entries := []dumpEntry{}
+	for _, section := range sectionNames(conf.metadata) {
+		for _, param := range conf.metadata[section] {
+			entries = conf.appendEntry(entries, section, param.Name, conf.paramName(param.Name, param.Tag))
+		}
+	}
+	structured := make([]string, 0, len(conf.structured))
+	for section := range conf.structured {
+		structured = append(structured, section)
+	}
+	sort.Strings(structured)
+	for _, section := range structured {
+		for _, param := range conf.structured[section] {
+			entries = conf.appendEntry(entries, section, param.Name, conf.fieldName(param.Name, string(param.Tag)))
+		}
+	}
+	return dump(w, entries, opts)
+}
+
+func (base *WithConfigBase) appendEntry(entries []dumpEntry, section, option, name string) []dumpEntry {
+	if sect, ok := base.Sections[section]; ok {
+		if opt, ok := sect.Options[option]; ok {
+			entries = append(entries, dumpEntry{section: section, name: name, option: opt})
+		}
+	}
+	return entries
+}
+
+func dump(w io.Writer, entries []dumpEntry, opts DumpOptions) error {
+	// keep order of parameters from metadata, but group them by section
+	sort.SliceStable(entries, func(i, j int) bool { return entries[i].section < entries[j].section })
+
+	switch strings.ToLower(opts.Format) {
+	case "ini":
+		return dumpINI(w, entries, opts)
+	case "json":
+		return dumpJSON(w, entries, opts)
+	case "yaml", "yml":
+		return dumpYAML(w, entries, opts)
+	default:
+		return fmt.Errorf("unsupported dump format '%s'", opts.Format)
+	}
+}
+
+func dumpINI(w io.Writer, entries []dumpEntry, opts DumpOptions) error {
+	var buf bytes.Buffer
+	for i, entry := range entries {
+		if i == 0 || entries[i-1].section != entry.section {
+			if i > 0 {
+				buf.WriteString("\n")
+			}
+			fmt.Fprintf(&buf, "[%s]\n", entry.section)
+		}
+		if opts.Sources {
+			fmt.Fprintf(&buf, "# source: %s\n", entry.option.source)
+		}
+		fmt.Fprintf(&buf, "%s = %s\n", entry.name, valueString(visibleValue(entry.option, opts.ShowSecrets)))
+	}
+	_, err := w.Write(buf.Bytes())
+	return err
+}
+
+func dumpJSON(w io.Writer, entries []dumpEntry, opts DumpOptions) error {
+	output := make(map[string]map[string]interface{})
+	for _, entry := range entries {
+		if _, ok := output[entry.section]; !ok {
+			output[entry.section] = make(map[string]interface{})
+		}
+		value := dumpValue(entry.option, opts)
+		if opts.Sources {
+			value = map[string]interface{}{"value": value, "source": entry.option.source}
+		}
+		output[entry.section][entry.name] = value
+	}
+	// keep redacted values readable
+	encoder := json.NewEncoder(w)
+	encoder.SetEscapeHTML(false)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(output)
+}
+
+func dumpYAML(w io.Writer, entries []dumpEntry, opts DumpOptions) error {
+	root := &yaml.Node{Kind: yaml.MappingNode}
+	var section *yaml.Node
+	for i, entry := range entries {
+		if i == 0 || entries[i-1].section != entry.section {
+			section = &yaml.Node{Kind: yaml.MappingNode}
+			root.Content = append(root.Content, &yaml.Node{Kind: yaml.ScalarNode, Value: entry.section}, section)
+		}
+		value := &yaml.Node{}
+		if err := value.Encode(dumpValue(entry.option, opts)); err != nil {
+			return fmt.Errorf("failed to encode parameter %s.%s: %s", entry.section, entry.name, err)
+		}
+		key := &yaml.Node{Kind: yaml.ScalarNode, Value: entry.name}
+		if opts.Sources {
+			key.HeadComment = "source: " + entry.option.source
+		}
+		section.Content = append(section.Content, key, value)
+	}
+	encoder := yaml.NewEncoder(w)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(root); err != nil {
+		return err
+	}
+	return encoder.Close()
+}
+
+//dumpValue returns value of the option suitable for encoding to structured formats
+func dumpValue(opt *Option, opts DumpOptions) interface{} {
+	value := visibleValue(opt, opts.ShowSecrets)
+	if stringer, ok := value.(fmt.Stringer); ok {
+		return stringer.String()
+	}
+	return value
+}
+
+//visibleValue returns value of the option with secret values redacted unless showSecrets is set,
+//including fields of structured values tagged `secret:"true"` on any level
+func visibleValue(opt *Option, showSecrets bool) interface{} {
+	switch {
+	case showSecrets || opt.value == nil:
+		return opt.value
+	case opt.secret:
+		return redacted
+	default:
+		return redactSecrets(reflect.ValueOf(opt.value)).Interface()
+	}
+}
+
+//redactSecrets returns deep copy of the value with fields tagged `secret:"true"` set to redacted string,
+//or to zero value when they are not strings. Values without secret fields are returned as they are.
+func redactSecrets(value reflect.Value) reflect.Value {
+	switch value.Kind() {
+	case reflect.Struct:
+		output := reflect.New(value.Type()).Elem()
+		output.Set(value)
+		for i := 0; i < value.NumField(); i++ {
+			field := value.Type().Field(i)
+			switch {
+			case field.PkgPath != "":
+				continue
+			case field.Tag.Get("secret") == "true" && field.Type.Kind() == reflect.String:
+				output.Field(i).SetString(redacted)
+			case field.Tag.Get("secret") == "true":
+				output.Field(i).Set(reflect.Zero(field.Type))
+			default:
+				output.Field(i).Set(redactSecrets(value.Field(i)))
+			}
+		}
+		return output
+	case reflect.Ptr:
+		if value.IsNil() {
+			return value
+		}
+		output := reflect.New(value.Type().Elem())
+		output.Elem().Set(redactSecrets(value.Elem()))
+		return output
+	case reflect.Interface:
+		if value.IsNil() {
+			return value
+		}
+		output := reflect.New(value.Type()).Elem()
+		output.Set(redactSecrets(value.Elem()))
+		return output
+	case reflect.Slice, reflect.Array:
+		if value.Kind() == reflect.Slice && value.IsNil() {
+			return value
+		}
+		output := reflect.New(value.Type()).Elem()
+		if value.Kind() == reflect.Slice {
+			output.Set(reflect.MakeSlice(value.Type(), value.Len(), value.Len()))
+		}
+		for i := 0; i < value.Len(); i++ {
+			output.Index(i).Set(redactSecrets(value.Index(i)))
+		}
+		return output
+	case reflect.Map:
+		if value.IsNil() {
+			return value
+		}
+		output := reflect.MakeMapWithSize(value.Type(), value.Len())
+		iter := value.MapRange()
+		for iter.Next() {
+			output.SetMapIndex(iter.Key(), redactSecrets(iter.Value()))
+		}
+		return output
+	default:
+		return value
+	}
+}
+
+//valueString formats value of the option for INI file and diffs, lists are joined by comma
+//and structured values are encoded as JSON
+func valueString(value interface{}) string {
+	if value == nil {
+		return ""
+	}
+	if stringer, ok := value.(fmt.Stringer); ok {
+		return stringer.String()
+	}
+	val := reflect.ValueOf(value)
+	switch val.Kind() {
+	case reflect.Slice, reflect.Array:
+		items := make([]string, val.Len())
+		for i := range items {
+			item := val.Index(i)
+			switch item.Kind() {
+			case reflect.Map, reflect.Struct, reflect.Slice, reflect.Interface, reflect.Ptr:
+				return jsonString(value)
+			}
+			items[i] = fmt.Sprintf("%v", item.Interface())
+		}
+		return strings.Join(items, ",")
+	case reflect.Map, reflect.Struct, reflect.Ptr:
+		return jsonString(value)
+	default:
+		return fmt.Sprintf("%v", value)
+	}
+}
+
+func jsonString(value interface{}) string {
+	var buf bytes.Buffer
+	encoder := json.NewEncoder(&buf)
+	encoder.SetEscapeHTML(false)
+	if err := encoder.Encode(value); err != nil {
+		return fmt.Sprintf("%v", value)
+	}
+	return strings.TrimSuffix(buf.String(), "\n")
+}
+
+//Diff returns options which differ between given configs sorted by section and option name. Both configs
+//have to be created by one of the config constructors, options missing in old config are reported
+//as added, options missing in new config as removed.
+func Diff(old, new Config) ([]Change, error) {
+	oldConf, ok := old.(reloadable)
+	if !ok {
+		return nil, fmt.Errorf("unable to diff config of type %T", old)
+	}
+	newConf, ok := new.(reloadable)
+	if !ok {
+		return nil, fmt.Errorf("unable to diff config of type %T", new)
+	}
+	return diffSections(oldConf.sections(), newConf.sections()), nil
+}
+
+//String formats the change as "+ section.option = value" for added option, "- section.option = value"
+//for removed option and "~ section.option: old -> new" for changed option. Secret values, including
+//secret fields of structured values, are redacted.
+func (change Change) String() string {
+	name := fmt.Sprintf("%s.%s", change.Section, change.Option)
+	switch {
+	case change.Old == nil:
+		return fmt.Sprintf("+ %s = %s", name, printable(change.New))
+	case change.New == nil:
+		return fmt.Sprintf("- %s = %s", name, printable(change.Old))
+	default:
+		return fmt.Sprintf("~ %s: %s -> %s", name, printable(change.Old), printable(change.New))
+	}
+}
+
+func printable(opt *Option) string {
+	return valueString(visibleValue(opt, false))
+}
@@ -0,0 +1,343 @@
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+const jsonSchemaDraft = "http://json-schema.org/draft-07/schema#"
+
+//GenerateINI writes sample INI file for given metadata. Every parameter is set to its default value
+//and preceded by comments with its description and notes about required and deprecated parameters.
+func GenerateINI(w io.Writer, metadata map[string][]Parameter) error {
+	var buf bytes.Buffer
+	for i, section := range sectionNames(metadata) {
+		if i > 0 {
+			buf.WriteString("\n")
+		}
+		fmt.Fprintf(&buf, "[%s]\n", section)
+		for _, param := range metadata[section] {
+			for _, line := range paramComments(param) {
+				fmt.Fprintf(&buf, "# %s\n", line)
+			}
+			fmt.Fprintf(&buf, "%s = %s\n", param.Name, valueString(param.Default))
+		}
+	}
+	_, err := w.Write(buf.Bytes())
+	return err
+}
+
+//GenerateJSON writes sample JSON file for given metadata with every parameter set to its default value.
+//Parameter names are taken from json tags.
+func GenerateJSON(w io.Writer, metadata map[string][]Parameter) error {
+	return generateJSON(w, metadata, nil, jsonName)
+}
+
+//GenerateJSONSchema writes JSON Schema document describing JSON config file for given metadata.
+//Types of parameters are derived from their default values.
+func GenerateJSONSchema(w io.Writer, metadata map[string][]Parameter) error {
+	return generateJSONSchema(w, metadata, nil, jsonName)
+}
+
+//GenerateJSON writes sample config file in JSON format with every parameter set to its default value,
+//including structured parameters with defaults given by `default` tags of their fields.
+func (conf *documentConfig) GenerateJSON(w io.Writer) error {
+	return generateJSON(w, conf.metadata, conf.structured, conf.paramName)
+}
+
+//GenerateJSONSchema writes JSON Schema document describing the config file including structured parameters.
+//Schema of structured parameters respects `required` tags and `validate` tags with "options", "range",
+//"match", "url" and "duration" validators.
+func (conf *documentConfig) GenerateJSONSchema(w io.Writer) error {
+	return generateJSONSchema(w, conf.metadata, conf.structured, conf.paramName)
+}
+
+//paramComments returns documentation of the parameter for sample files
+func paramComments(param Parameter) []string {
+	lines := []string{}
+	if param.Description != "" {
+		lines = append(lines, param.Description)
+	}
+	if param.Required {
+		lines = append(lines, "required")
+	}
+	if param.Deprecated != "" {
+		lines = append(lines, "deprecated: "+param.Deprecated)
+	}
+	return lines
+}
+
+func jsonName(name, tag string) string {
+	if tagName := strings.Split(reflect.StructTag(tag).Get("json"), ",")[0]; tagName != "" && tagName != "-" {
+		return tagName
+	}
+	return name
+}
+
+//sampleValue returns default value of the structured parameter with defaults of all its fields applied
+func sampleValue(param reflect.StructField) (interface{}, error) {
+	value := reflect.New(param.Type).Elem()
+	if err := applyDefaults(param.Name, param, value); err != nil {
+		return nil, err
+	}
+	return value.Interface(), nil
+}
+
+//applyDefaults sets the value and its fields on any level to values of their `default` tags. Validators
+//are not run, so that defaults don't have to be valid on the machine generating the sample (eg. paths
+//of files which have to exist).
+func applyDefaults(path string, field reflect.StructField, value reflect.Value) error {
+	if def, ok := field.Tag.Lookup("default"); ok {
+		if err := setValue(value, def); err != nil {
+			return fmt.Errorf("invalid default value of parameter %s: %s", path, err)
+		}
+	}
+	switch value.Kind() {
+	case reflect.Struct:
+		for i := 0; i < value.NumField(); i++ {
+			subfield := value.Type().Field(i)
+			if subfield.PkgPath != "" {
+				continue
+			}
+			if err := applyDefaults(fmt.Sprintf("%s.%s", path, subfield.Name), subfield, value.Field(i)); err != nil {
+				return err
+			}
+		}
+	case reflect.Ptr:
+		if !value.IsNil() {
+			return applyDefaults(path, reflect.StructField{Name: field.Name, Type: value.Type().Elem()}, value.Elem())
+		}
+	}
+	return nil
+}
+
+func generateJSON(w io.Writer, metadata map[string][]Parameter, structured map[string][]reflect.StructField, name func(string, string) string) error {
+	output := make(map[string]map[string]interface{})
+	for section, params := range metadata {
+		output[section] = make(map[string]interface{})
+		for _, param := range params {
+			value := param.Default
+			if stringer, ok := value.(fmt.Stringer); ok {
+				value = stringer.String()
+			}
+			output[section][name(param.Name, param.Tag)] = value
+		}
+	}
+	for section, params := range structured {
+		if _, ok := output[section]; !ok {
+			output[section] = make(map[string]interface{})
+		}
+		for _, param := range params {
+			value, err := sampleValue(param)
+			if err != nil {
+				return err
+			}
+			output[section][name(param.Name, string(param.Tag))] = value
+		}
+	}
+	data, err := json.MarshalIndent(output, "", "  ")
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(append(data, '\n'))
+	return err
+}
+
+func generateJSONSchema(w io.Writer, metadata map[string][]Parameter, structured map[string][]reflect.StructField, name func(string, string) string) error {
+	sections := make(map[string]interface{})
+	section := func(sectionName string) map[string]interface{} {
+		if _, ok := sections[sectionName]; !ok {
+			sections[sectionName] = map[string]interface{}{
+				"type":       "object",
+				"properties": make(map[string]interface{}),
+			}
+		}
+		return sections[sectionName].(map[string]interface{})
+	}
+	addRequired := func(schema map[string]interface{}, name string) {
+		required, _ := schema["required"].([]string)
+		schema["required"] = append(required, name)
+	}
+
+	for _, sectionName := range sectionNames(metadata) {
+		sectionSchema := section(sectionName)
+		for _, param := range metadata[sectionName] {
+			paramName := name(param.Name, param.Tag)
+			schema := make(map[string]interface{})
+			if kind := schemaType(reflect.TypeOf(param.Default)); kind != "" {
+				schema["type"] = kind
+			}
+			if param.Default != nil {
+				value := param.Default
+				if stringer, ok := value.(fmt.Stringer); ok {
+					value = stringer.String()
+					schema["type"] = "string"
+				}
+				schema["default"] = value
+			}
+			if param.Description != "" {
+				schema["description"] = param.Description
+			}
+			if param.Deprecated != "" {
+				schema["deprecated"] = true
+			}
+			if param.Required {
+				addRequired(sectionSchema, paramName)
+			}
+			sectionSchema["properties"].(map[string]interface{})[paramName] = schema
+		}
+	}
+	for sectionName, params := range structured {
+		sectionSchema := section(sectionName)
+		for _, param := range params {
+			paramName := name(param.Name, string(param.Tag))
+			schema, err := fieldSchema(param, name, make(map[reflect.Type]bool))
+			if err != nil {
+				return fmt.Errorf("failed to generate schema of parameter %s.%s: %s", sectionName, param.Name, err)
+			}
+			if param.Tag.Get("required") == "true" {
+				addRequired(sectionSchema, paramName)
+			}
+			sectionSchema["properties"].(map[string]interface{})[paramName] = schema
+		}
+	}
+
+	document := map[string]interface{}{
+		"$schema":    jsonSchemaDraft,
+		"type":       "object",
+		"properties": sections,
+	}
+	data, err := json.MarshalIndent(document, "", "  ")
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(append(data, '\n'))
+	return err
+}
+
+//schemaType returns JSON Schema type for given Go type or empty string if there is none
+func schemaType(t reflect.Type) string {
+	if t == nil {
+		return ""
+	}
+	switch t.Kind() {
+	case reflect.String:
+		return "string"
+	case reflect.Bool:
+		return "boolean"
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return "integer"
+	case reflect.Float32, reflect.Float64:
+		return "number"
+	case reflect.Slice, reflect.Array:
+		return "array"
+	case reflect.Map, reflect.Struct:
+		return "object"
+	case reflect.Ptr:
+		return schemaType(t.Elem())
+	default:
+		return ""
+	}
+}
+
+//fieldSchema returns JSON Schema of the struct field according to its type and tags
+func fieldSchema(field reflect.StructField, name func(string, string) string, visiting map[reflect.Type]bool) (map[string]interface{}, error) {
+	schema, err := typeSchema(field.Type, name, visiting)
+	if err != nil {
+		return nil, err
+	}
+	if def, ok := field.Tag.Lookup("default"); ok {
+		value := reflect.New(field.Type).Elem()
+		if err := setValue(value, def); err != nil {
+			return nil, fmt.Errorf("invalid default value: %s", err)
+		}
+		schema["default"] = value.Interface()
+	}
+	for _, item := range splitValidatorSpec(field.Tag.Get("validate")) {
+		parts := strings.SplitN(item, "=", 2)
+		arg := ""
+		if len(parts) > 1 {
+			arg = parts[1]
+		}
+		switch parts[0] {
+		case "options":
+			schema["enum"] = strings.Split(arg, "|")
+		case "match":
+			schema["pattern"] = arg
+		case "url":
+			schema["format"] = "uri"
+		case "duration":
+			schema["type"] = []string{"string", "integer"}
+		case "range":
+			bounds := strings.Split(arg, ":")
+			for i, key := range []string{"minimum", "maximum"} {
+				if i < len(bounds) && bounds[i] != "" {
+					if number, err := strconv.ParseFloat(bounds[i], 64); err == nil {
+						schema[key] = number
+					}
+				}
+			}
+		}
+	}
+	return schema, nil
+}
+
+//typeSchema returns JSON Schema of given Go type. Struct types already being described in visiting
+//are described only as objects without properties, so that recursive types don't recurse infinitely.
+func typeSchema(t reflect.Type, name func(string, string) string, visiting map[reflect.Type]bool) (map[string]interface{}, error) {
+	schema := make(map[string]interface{})
+	if kind := schemaType(t); kind != "" {
+		schema["type"] = kind
+	}
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	switch t.Kind() {
+	case reflect.Struct:
+		if visiting[t] {
+			break
+		}
+		visiting[t] = true
+		defer delete(visiting, t)
+		properties := make(map[string]interface{})
+		required := []string{}
+		for i := 0; i < t.NumField(); i++ {
+			field := t.Field(i)
+			if field.PkgPath != "" {
+				continue
+			}
+			fieldName := name(field.Name, string(field.Tag))
+			fieldSchema, err := fieldSchema(field, name, visiting)
+			if err != nil {
+				return nil, fmt.Errorf("field %s: %s", field.Name, err)
+			}
+			properties[fieldName] = fieldSchema
+			if field.Tag.Get("required") == "true" {
+				required = append(required, fieldName)
+			}
+		}
+		schema["properties"] = properties
+		if len(required) > 0 {
+			schema["required"] = required
+		}
+	case reflect.Slice, reflect.Array:
+		items, err := typeSchema(t.Elem(), name, visiting)
+		if err != nil {
+			return nil, err
+		}
+		schema["items"] = items
+	case reflect.Map:
+		values, err := typeSchema(t.Elem(), name, visiting)
+		if err != nil {
+			return nil, err
+		}
+		schema["additionalProperties"] = values
+	}
+	return schema, nil
+}
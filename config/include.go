@@ -0,0 +1,132 @@
+package config
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+//IncludeKey is the name of the directive including other files or directories into the config file.
+//In INI files it is key of the default section (before first section header) holding comma separated list,
+//in other formats it is top-level key holding string or list of strings. Relative paths are resolved
+//against directory of the including file and can contain glob patterns. Included files are loaded before
+//the including file, so its values take precedence.
+const IncludeKey = "include"
+
+//formatExtensions holds extensions of files loaded from config directories per format
+var formatExtensions = map[string][]string{
+	"INI":  {".ini", ".conf"},
+	"JSON": {".json"},
+	"YAML": {".yaml", ".yml"},
+	"TOML": {".toml"},
+}
+
+//fileResolver expands config path to ordered list of files to load. Directories (conf.d style) are
+//expanded to files with extension of the format in lexical order, include directives read by includes
+//function are expanded before the including file.
+type fileResolver struct {
+	extensions []string
+	includes   func(path string) ([]string, error)
+	visiting   map[string]bool
+	output     []string
+}
+
+func resolveFiles(path string, extensions []string, includes func(string) ([]string, error)) ([]string, error) {
+	resolver := fileResolver{extensions: extensions, includes: includes, visiting: make(map[string]bool)}
+	if err := resolver.resolve(path); err != nil {
+		return nil, err
+	}
+	return resolver.output, nil
+}
+
+func (res *fileResolver) resolve(path string) error {
+	info, err := os.Stat(path)
+	if err != nil {
+		return err
+	}
+	if info.IsDir() {
+		return res.resolveDir(path)
+	}
+
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return err
+	}
+	if res.visiting[abs] {
+		return fmt.Errorf("include cycle detected at %s", path)
+	}
+	res.visiting[abs] = true
+	defer delete(res.visiting, abs)
+
+	patterns, err := res.includes(path)
+	if err != nil {
+		return err
+	}
+	for _, pattern := range patterns {
+		if !filepath.IsAbs(pattern) {
+			pattern = filepath.Join(filepath.Dir(path), pattern)
+		}
+		matches, err := filepath.Glob(pattern)
+		if err != nil {
+			return fmt.Errorf("invalid include pattern %s in %s: %s", pattern, path, err)
+		}
+		if len(matches) == 0 && !strings.ContainsAny(pattern, "*?[") {
+			return fmt.Errorf("included file %s in %s does not exist", pattern, path)
+		}
+		sort.Strings(matches)
+		for _, match := range matches {
+			if err := res.resolve(match); err != nil {
+				return err
+			}
+		}
+	}
+	res.output = append(res.output, path)
+	return nil
+}
+
+func (res *fileResolver) resolveDir(path string) error {
+	files, err := ioutil.ReadDir(path)
+	if err != nil {
+		return err
+	}
+	// ReadDir returns files sorted by name
+	for _, file := range files {
+		if file.IsDir() || strings.HasPrefix(file.Name(), ".") {
+			continue
+		}
+		for _, ext := range res.extensions {
+			if strings.EqualFold(filepath.Ext(file.Name()), ext) {
+				if err := res.resolve(filepath.Join(path, file.Name())); err != nil {
+					return err
+				}
+				break
+			}
+		}
+	}
+	return nil
+}
+
+//includePatterns converts value of include directive in structured formats to list of patterns
+func includePatterns(value interface{}, path string) ([]string, error) {
+	switch val := value.(type) {
+	case nil:
+		return nil, nil
+	case string:
+		return []string{val}, nil
+	case []interface{}:
+		patterns := make([]string, 0, len(val))
+		for _, item := range val {
+			pattern, ok := item.(string)
+			if !ok {
+				return nil, fmt.Errorf("invalid %s directive in %s: %v is not string", IncludeKey, path, item)
+			}
+			patterns = append(patterns, pattern)
+		}
+		return patterns, nil
+	default:
+		return nil, fmt.Errorf("invalid %s directive in %s: expected string or list of strings", IncludeKey, path)
+	}
+}
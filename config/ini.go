@@ -1,6 +1,7 @@
 package config
 
 import (
+	"flag"
 	"fmt"
 	"strings"
 
@@ -13,51 +14,105 @@ type INIConfig struct {
 	WithConfigBase
 }
 
+var iniOptions = ini.LoadOptions{
+	AllowPythonMultilineValues: true,
+	IgnoreInlineComment:        true,
+}
+
 //NewINIConfig creates and initializes new INIConfig object according to given metadata
 func NewINIConfig(metadata map[string][]Parameter, logger *logging.Logger) *INIConfig {
 	return &INIConfig{
-		WithConfigBase: WithConfigBase{log: logger, metadata: metadata, Sections: make(map[string]*Section)},
+		WithConfigBase: newConfigBase(metadata, logger),
 	}
 }
 
-//Parse loads data from given file
+//BindFlags defines command-line flag named "<section>.<parameter>" for every parameter in the metadata.
+//Values of flags set on command line take precedence over environment and config file when Parse is
+//called after the flags are parsed. Call SetEnvPrefix first to include names of environment variables
+//in the help text.
+func (conf *INIConfig) BindFlags(flags *flag.FlagSet) {
+	conf.bindFlags(flags, func(param Parameter) string { return param.Name })
+}
+
+//Parse loads data from given file. Path can be also directory, in which case all ".ini" and ".conf" files
+//in it are loaded in lexical order with values of later files overriding former ones. Files can include
+//other files or directories by IncludeKey directive.
 func (conf INIConfig) Parse(path string) error {
-	options := ini.LoadOptions{
-		AllowPythonMultilineValues: true,
-		IgnoreInlineComment:        true,
+	files, err := resolveFiles(path, formatExtensions["INI"], iniIncludes)
+	if err != nil {
+		return err
 	}
-	data, err := ini.LoadSources(options, path)
+	if len(files) == 0 {
+		return fmt.Errorf("no config files found in %s", path)
+	}
+	// values from later files override values from former ones
+	sources := make([]interface{}, len(files))
+	for i, file := range files {
+		sources[i] = file
+	}
+	data, err := ini.LoadSources(iniOptions, sources[0], sources[1:]...)
 	if err != nil {
 		return err
 	}
-	for sectionName, sectionMetadata := range conf.metadata {
+	data.Section(ini.DefaultSection).DeleteKey(IncludeKey)
+	conf.resetRaw()
+	for _, sectionData := range data.Sections() {
+		if sectionData.Name() == ini.DefaultSection && len(sectionData.Keys()) == 0 {
+			// implicit section created by the library for keys without section
+			continue
+		}
+		values := make(map[string]interface{})
+		for _, key := range sectionData.Keys() {
+			values[key.Name()] = key.Value()
+		}
+		conf.raw[sectionData.Name()] = values
+	}
+
+	known := make(map[string][]string)
+	for section, params := range conf.metadata {
+		for _, param := range params {
+			known[section] = append(known[section], param.Name)
+		}
+	}
+	errs := conf.checkUnknown(known)
+	for _, sectionName := range sectionNames(conf.metadata) {
 		conf.Sections[sectionName] = &Section{Options: make(map[string]*Option)}
-		if sectionData, err := data.GetSection(sectionName); err == nil {
-			for _, param := range sectionMetadata {
-				var value interface{}
+		// missing section means default values for the whole section
+		sectionData, _ := data.GetSection(sectionName)
+		for _, param := range conf.metadata[sectionName] {
+			var value interface{}
+			if sectionData != nil {
 				if paramData, err := sectionData.GetKey(param.Name); err == nil {
 					value = paramData.Value()
-				} else {
-					value = nil
-				}
-				if opt, err := createOption(value, param, conf.log); err == nil {
-					conf.Sections[sectionName].Options[param.Name] = opt
-				} else {
-					return err
 				}
 			}
-		} else {
-			// default values for the whole section
-			for _, param := range sectionMetadata {
-				if opt, err := createOption(nil, param, conf.log); err == nil {
-					conf.Sections[sectionName].Options[param.Name] = opt
-				} else {
-					return err
-				}
+			opt, err := conf.loadOption(sectionName, param.Name, param, value, func(value string) interface{} { return value })
+			if err != nil {
+				errs = append(errs, err)
+				continue
+			}
+			conf.Sections[sectionName].Options[param.Name] = opt
+		}
+	}
+	errs = append(errs, conf.validateSections()...)
+	return errs.errorOrNil()
+}
+
+//iniIncludes returns patterns of files included by given INI file
+func iniIncludes(file string) ([]string, error) {
+	data, err := ini.LoadSources(iniOptions, file)
+	if err != nil {
+		return nil, err
+	}
+	patterns := []string{}
+	if section := data.Section(ini.DefaultSection); section.HasKey(IncludeKey) {
+		for _, pattern := range strings.Split(section.Key(IncludeKey).String(), ",") {
+			if pattern = strings.TrimSpace(pattern); pattern != "" {
+				patterns = append(patterns, pattern)
 			}
 		}
 	}
-	return nil
+	return patterns, nil
 }
 
 //GetOption returns Option objects according to given "section-name/option-name" string.
@@ -0,0 +1,232 @@
+package config
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/infrawatch/apputils/logging"
+)
+
+//StrictMode defines how sections and options found in config file but missing in metadata are reported
+type StrictMode int
+
+const (
+	//StrictOff ignores unknown sections and options
+	StrictOff StrictMode = iota
+	//StrictWarn logs warning for every unknown section and option
+	StrictWarn
+	//StrictError makes Parse fail with error for every unknown section and option
+	StrictError
+)
+
+//SetStrict sets reporting of sections and options which are present in config file, but are not described
+//by metadata (eg. typos like "adress"). Config files of structured formats are checked for unknown top-level
+//values and for unknown keys nested in structured parameters too. Reports contain suggestion of the closest
+//known name.
+func (base *WithConfigBase) SetStrict(mode StrictMode) {
+	base.strict = mode
+}
+
+//checkUnknown reports sections and options of the parsed file missing in known names (section name to names
+//of options in config file) together with unknown values found by checks specific for the format. Returns
+//errors in StrictError mode, logs warnings in StrictWarn mode.
+func (base *WithConfigBase) checkUnknown(known map[string][]string, found ...error) ValidationErrors {
+	errs := ValidationErrors{}
+	if base.strict == StrictOff {
+		return errs
+	}
+	knownSections := make([]string, 0, len(known))
+	for section := range known {
+		knownSections = append(knownSections, section)
+	}
+
+	sections := make([]string, 0, len(base.raw))
+	for section := range base.raw {
+		sections = append(sections, section)
+	}
+	sort.Strings(sections)
+	for _, section := range sections {
+		options, ok := known[section]
+		if !ok {
+			errs = append(errs, unknownError(fmt.Sprintf("unknown section '%s'", section), section, knownSections))
+			continue
+		}
+		names := make([]string, 0, len(base.raw[section]))
+		for name := range base.raw[section] {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			found := false
+			for _, option := range options {
+				if option == name {
+					found = true
+					break
+				}
+			}
+			if !found {
+				errs = append(errs, unknownError(fmt.Sprintf("unknown option '%s' in section '%s'", name, section), name, options))
+			}
+		}
+	}
+	errs = append(errs, found...)
+
+	if base.strict == StrictWarn {
+		for _, err := range errs {
+			base.log.Warn("unknown configuration value.", logging.Metadata{"error": err})
+		}
+		return ValidationErrors{}
+	}
+	return errs
+}
+
+//unknownValues returns errors for top-level values of the parsed document which are not sections and for keys
+//nested in structured parameters which don't match any field of the parameter
+func (conf documentConfig) unknownValues(document map[string]interface{}) []error {
+	errs := []error{}
+	known := conf.knownNames()
+	knownSections := make([]string, 0, len(known))
+	for section := range known {
+		knownSections = append(knownSections, section)
+	}
+	for _, key := range sortedKeys(document) {
+		if _, ok := document[key].(map[string]interface{}); ok {
+			// sections are checked by checkUnknown
+			continue
+		}
+		if _, ok := known[key]; !ok {
+			errs = append(errs, unknownError(fmt.Sprintf("unknown top-level option '%s'", key), key, knownSections))
+		}
+	}
+
+	structuredSections := make([]string, 0, len(conf.structured))
+	for section := range conf.structured {
+		structuredSections = append(structuredSections, section)
+	}
+	sort.Strings(structuredSections)
+	for _, section := range structuredSections {
+		sectValue, _ := conf.lookupKey(document, section)
+		sectTree, _ := sectValue.(map[string]interface{})
+		for _, param := range conf.structured[section] {
+			if tree, ok := conf.lookupKey(sectTree, conf.fieldName(param.Name, string(param.Tag))); ok {
+				errs = append(errs, conf.unknownFields(fmt.Sprintf("%s.%s", section, param.Name), param.Type, tree)...)
+			}
+		}
+	}
+	return errs
+}
+
+//unknownFields returns errors for keys of the tree on any level which don't match fields of given type. Keys
+//are matched to fields the same way the decoder of the format matches them. Path is used in error messages.
+func (conf documentConfig) unknownFields(path string, t reflect.Type, tree interface{}) []error {
+	errs := []error{}
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	value := reflect.ValueOf(tree)
+	switch t.Kind() {
+	case reflect.Struct:
+		values, ok := tree.(map[string]interface{})
+		if !ok {
+			return errs
+		}
+		fields := make(map[string]interface{})
+		names := []string{}
+		for i := 0; i < t.NumField(); i++ {
+			field := t.Field(i)
+			if field.PkgPath != "" || field.Tag.Get(conf.tagKey) == "-" {
+				continue
+			}
+			name := conf.fieldName(field.Name, string(field.Tag))
+			fields[name] = field
+			names = append(names, name)
+		}
+		for _, key := range sortedKeys(values) {
+			field, ok := conf.lookupKey(fields, key)
+			if !ok {
+				errs = append(errs, unknownError(fmt.Sprintf("unknown field '%s' of parameter %s", key, path), key, names))
+				continue
+			}
+			subfield := field.(reflect.StructField)
+			errs = append(errs, conf.unknownFields(fmt.Sprintf("%s.%s", path, subfield.Name), subfield.Type, values[key])...)
+		}
+	case reflect.Slice, reflect.Array:
+		if value.Kind() == reflect.Slice || value.Kind() == reflect.Array {
+			for i := 0; i < value.Len(); i++ {
+				errs = append(errs, conf.unknownFields(fmt.Sprintf("%s[%d]", path, i), t.Elem(), value.Index(i).Interface())...)
+			}
+		}
+	case reflect.Map:
+		if values, ok := tree.(map[string]interface{}); ok {
+			for _, key := range sortedKeys(values) {
+				errs = append(errs, conf.unknownFields(fmt.Sprintf("%s[%s]", path, key), t.Elem(), values[key])...)
+			}
+		}
+	}
+	return errs
+}
+
+func sortedKeys(values map[string]interface{}) []string {
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func unknownError(msg, name string, known []string) error {
+	if suggestion := suggest(name, known); suggestion != "" {
+		return fmt.Errorf("%s (did you mean '%s'?)", msg, suggestion)
+	}
+	return fmt.Errorf("%s", msg)
+}
+
+//suggest returns known name closest to given name, or empty string if none of them is close enough
+func suggest(name string, known []string) string {
+	best := ""
+	bestDistance := len(name)/2 + 1
+	sorted := append([]string{}, known...)
+	sort.Strings(sorted)
+	for _, candidate := range sorted {
+		distance := editDistance(strings.ToLower(name), strings.ToLower(candidate))
+		if distance < bestDistance {
+			best, bestDistance = candidate, distance
+		}
+	}
+	return best
+}
+
+//editDistance returns Levenshtein distance of given strings
+func editDistance(a, b string) int {
+	first, second := []rune(a), []rune(b)
+	previous := make([]int, len(second)+1)
+	current := make([]int, len(second)+1)
+	for j := range previous {
+		previous[j] = j
+	}
+	for i := 1; i <= len(first); i++ {
+		current[0] = i
+		for j := 1; j <= len(second); j++ {
+			cost := 1
+			if first[i-1] == second[j-1] {
+				cost = 0
+			}
+			current[j] = minInt(previous[j]+1, current[j-1]+1, previous[j-1]+cost)
+		}
+		previous, current = current, previous
+	}
+	return previous[len(second)]
+}
+
+func minInt(values ...int) int {
+	output := values[0]
+	for _, value := range values[1:] {
+		if value < output {
+			output = value
+		}
+	}
+	return output
+}
@@ -0,0 +1,67 @@
+package config
+
+import (
+	"fmt"
+	"reflect"
+)
+
+//validateStructured applies defaults, required flags and validators given by `default`, `required` and `validate`
+//tags of the structured parameter and all its nested fields. Value has to be settable, tree holds the generic
+//form of the parsed value used to find out which fields were present in config file. Path is used in error
+//messages, eg. Amqp1.Connection.Address. Required fields are checked only when the enclosing value is present.
+func (conf documentConfig) validateStructured(path string, field reflect.StructField, value reflect.Value, tree interface{}, present, parentPresent bool) ValidationErrors {
+	errs := ValidationErrors{}
+	if !present {
+		if field.Tag.Get("required") == "true" && parentPresent {
+			return append(errs, fmt.Errorf("missing required parameter %s", path))
+		}
+		if def, ok := field.Tag.Lookup("default"); ok {
+			if err := setValue(value, def); err != nil {
+				return append(errs, fmt.Errorf("invalid default value of parameter %s: %s", path, err))
+			}
+			present = true
+		}
+	}
+	if spec := field.Tag.Get("validate"); spec != "" && present {
+		validators, err := ParseValidators(spec)
+		if err != nil {
+			return append(errs, fmt.Errorf("failed validation for parameter %s: %s", path, err))
+		}
+		validated, err := validate(value.Interface(), validators)
+		if err == nil {
+			err = setValue(value, validated)
+		}
+		if err != nil {
+			return append(errs, fmt.Errorf("failed validation for parameter %s: %s", path, err))
+		}
+	}
+
+	switch value.Kind() {
+	case reflect.Struct:
+		subtree, _ := tree.(map[string]interface{})
+		for i := 0; i < value.NumField(); i++ {
+			subfield := value.Type().Field(i)
+			if subfield.PkgPath != "" {
+				continue
+			}
+			subvalue, ok := conf.lookupKey(subtree, conf.fieldName(subfield.Name, string(subfield.Tag)))
+			subpath := fmt.Sprintf("%s.%s", path, subfield.Name)
+			errs = append(errs, conf.validateStructured(subpath, subfield, value.Field(i), subvalue, ok, present)...)
+		}
+	case reflect.Slice, reflect.Array:
+		items, _ := tree.([]interface{})
+		for i := 0; i < value.Len(); i++ {
+			var item interface{}
+			if i < len(items) {
+				item = items[i]
+			}
+			itemField := reflect.StructField{Name: field.Name, Type: value.Type().Elem()}
+			errs = append(errs, conf.validateStructured(fmt.Sprintf("%s[%d]", path, i), itemField, value.Index(i), item, true, true)...)
+		}
+	case reflect.Ptr:
+		if !value.IsNil() {
+			errs = append(errs, conf.validateStructured(path, reflect.StructField{Name: field.Name, Type: value.Type().Elem()}, value.Elem(), tree, present, parentPresent)...)
+		}
+	}
+	return errs
+}
@@ -0,0 +1,28 @@
+package config
+
+import (
+	"bytes"
+
+	"github.com/BurntSushi/toml"
+	"github.com/infrawatch/apputils/logging"
+)
+
+//TOMLConfig holds complete configuration data and metadata for configuration saved as TOML file.
+//Names of flat parameters in the file are taken from toml tags, json tags are used when toml tag is missing.
+//Structured parameters and their fields are named as by the TOML decoder, ie. by toml tags or by field names.
+type TOMLConfig struct {
+	documentConfig
+}
+
+//NewTOMLConfig creates and initializes new config object according to given metadata.
+func NewTOMLConfig(metadata map[string][]Parameter, logger *logging.Logger) *TOMLConfig {
+	return &TOMLConfig{documentConfig: newDocumentConfig(metadata, logger, "TOML", "toml", toml.Unmarshal, marshalTOML)}
+}
+
+func marshalTOML(value interface{}) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := toml.NewEncoder(&buf).Encode(value); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,117 @@
+package config
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/infrawatch/apputils/logging"
+)
+
+//ValidationErrors holds all errors found during parsing of the config, so that every misconfigured
+//parameter is reported at once instead of only the first one.
+type ValidationErrors []error
+
+func (errs ValidationErrors) Error() string {
+	if len(errs) == 1 {
+		return errs[0].Error()
+	}
+	msgs := make([]string, len(errs))
+	for i, err := range errs {
+		msgs[i] = err.Error()
+	}
+	return fmt.Sprintf("%d errors in configuration: %s", len(errs), strings.Join(msgs, "; "))
+}
+
+//errorOrNil returns nil for empty list of errors, so that the result can be returned as error
+func (errs ValidationErrors) errorOrNil() error {
+	if len(errs) == 0 {
+		return nil
+	}
+	return errs
+}
+
+//SectionValidator checks validity of the section as whole, eg. rules involving more than one parameter.
+//It is called after all parameters of the section were parsed and validated.
+type SectionValidator func(section *Section) error
+
+//RequireOneOf creates section validator checking that at least one of given options has non-empty value.
+func RequireOneOf(names ...string) SectionValidator {
+	return func(section *Section) error {
+		for _, name := range names {
+			if opt, ok := section.Options[name]; ok && opt != nil && opt.value != nil && fmt.Sprintf("%v", opt.value) != "" {
+				return nil
+			}
+		}
+		return fmt.Errorf("at least one of parameters %s has to be set", strings.Join(names, ", "))
+	}
+}
+
+//AddSectionValidator adds validator which is run for given section on every Parse. Errors of section
+//validators are reported together with errors of single parameters.
+func (base *WithConfigBase) AddSectionValidator(section string, validator SectionValidator) {
+	if base.sectionValidators == nil {
+		base.sectionValidators = make(map[string][]SectionValidator)
+	}
+	base.sectionValidators[section] = append(base.sectionValidators[section], validator)
+}
+
+//validateSections runs section validators and returns errors found
+func (base *WithConfigBase) validateSections() ValidationErrors {
+	names := make([]string, 0, len(base.sectionValidators))
+	for name := range base.sectionValidators {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	errs := ValidationErrors{}
+	for _, name := range names {
+		section, ok := base.Sections[name]
+		if !ok {
+			section = &Section{Options: make(map[string]*Option)}
+		}
+		for _, validator := range base.sectionValidators[name] {
+			if err := validator(section); err != nil {
+				errs = append(errs, fmt.Errorf("failed validation of section %s: %s", name, err))
+			}
+		}
+	}
+	return errs
+}
+
+//loadOption creates option for the parameter from value found in config file (nil when missing) taking
+//overrides into account, convert transforms override values to the type the file format would produce.
+func (base *WithConfigBase) loadOption(section, name string, param Parameter, value interface{}, convert func(string) interface{}) (*Option, error) {
+	source := "parsed"
+	ovr, overridden := base.lookupOverride(section, name)
+	if overridden {
+		value = convert(ovr.value)
+		source = ovr.source
+	}
+	if value == nil && param.Required {
+		return nil, fmt.Errorf("missing required parameter %s in section %s", param.Name, section)
+	}
+	if value != nil && param.Deprecated != "" {
+		base.log.Warn("deprecated configuration parameter is set.", logging.Metadata{
+			"section":   section,
+			"parameter": param.Name,
+			"source":    source,
+			"hint":      param.Deprecated,
+		})
+	}
+	opt, err := createOption(value, source, param, base.log)
+	if err != nil && overridden {
+		err = ovr.wrapError(err)
+	}
+	return opt, err
+}
+
+//sectionNames returns names of sections in the metadata in lexical order
+func sectionNames(metadata map[string][]Parameter) []string {
+	output := make([]string, 0, len(metadata))
+	for name := range metadata {
+		output = append(output, name)
+	}
+	sort.Strings(output)
+	return output
+}
@@ -2,9 +2,18 @@ package config
 
 import (
 	"fmt"
+	"math"
+	"net"
+	"net/url"
+	"os"
+	"path/filepath"
 	"reflect"
+	"regexp"
 	"strconv"
 	"strings"
+	"sync"
+
+	"github.com/infrawatch/apputils/logging"
 )
 
 //StringOptionsValidatorFactory creates validator for checking if the validator's given value is one of the factory's given string options.
@@ -102,4 +111,337 @@ func MultiIntValidatorFactory(separator string) Validator {
 	}
 }
 
-//TODO: Add more builtin validators
+//LogLevelValidatorFactory creates validator for checking if the validator's given value is name of log level.
+//The validator returns corresponding logging.LogLevel.
+func LogLevelValidatorFactory() Validator {
+	return func(input interface{}) (interface{}, error) {
+		switch val := input.(type) {
+		case logging.LogLevel:
+			return val, nil
+		case string:
+			return logging.ParseLogLevel(val)
+		default:
+			return nil, fmt.Errorf("value (%v) is not log level", input)
+		}
+	}
+}
+
+//DurationValidatorFactory creates validator for checking if the validator's given value is duration in format
+//accepted by time.ParseDuration (eg. "1m30s"). Numbers are taken as nanoseconds. The validator returns time.Duration.
+func DurationValidatorFactory() Validator {
+	return func(input interface{}) (interface{}, error) {
+		return toDuration(input)
+	}
+}
+
+var byteSizeUnits = map[string]int64{
+	"":    1,
+	"b":   1,
+	"k":   1 << 10,
+	"kb":  1000,
+	"kib": 1 << 10,
+	"m":   1 << 20,
+	"mb":  1000 * 1000,
+	"mib": 1 << 20,
+	"g":   1 << 30,
+	"gb":  1000 * 1000 * 1000,
+	"gib": 1 << 30,
+	"t":   1 << 40,
+	"tb":  1000 * 1000 * 1000 * 1000,
+	"tib": 1 << 40,
+}
+
+var byteSizePattern = regexp.MustCompile(`^([0-9]+(?:\.[0-9]+)?)\s*([a-zA-Z]*)$`)
+
+//ByteSizeValidatorFactory creates validator for checking if the validator's given value is size in bytes,
+//eg. "512", "10MiB" or "1.5GB". Units are case insensitive, KB/MB/GB/TB are decimal, KiB/MiB/GiB/TiB
+//and K/M/G/T are binary. The validator returns number of bytes as int64.
+func ByteSizeValidatorFactory() Validator {
+	return func(input interface{}) (interface{}, error) {
+		str, ok := input.(string)
+		if !ok {
+			number, err := toInt64(input)
+			if err != nil || number < 0 {
+				return nil, fmt.Errorf("value (%v) is not byte size", input)
+			}
+			return number, nil
+		}
+		match := byteSizePattern.FindStringSubmatch(strings.TrimSpace(str))
+		if match == nil {
+			return nil, fmt.Errorf("value (%v) is not byte size", input)
+		}
+		unit, ok := byteSizeUnits[strings.ToLower(match[2])]
+		if !ok {
+			return nil, fmt.Errorf("value (%v) has unknown size unit '%s'", input, match[2])
+		}
+		number, err := strconv.ParseFloat(match[1], 64)
+		if err != nil || number*float64(unit) >= math.MaxInt64 {
+			return nil, fmt.Errorf("value (%v) is out of range of byte size", input)
+		}
+		return int64(number * float64(unit)), nil
+	}
+}
+
+//URLValidatorFactory creates validator for checking if the validator's given value is absolute URL with one
+//of given schemes (any scheme is allowed when none is given). The validator returns normalized URL string.
+func URLValidatorFactory(schemes []string) Validator {
+	return func(input interface{}) (interface{}, error) {
+		str, ok := input.(string)
+		if !ok {
+			return nil, fmt.Errorf("value (%v) is not URL", input)
+		}
+		parsed, err := url.Parse(strings.TrimSpace(str))
+		if err != nil || parsed.Scheme == "" || parsed.Host == "" {
+			return nil, fmt.Errorf("value (%v) is not URL", input)
+		}
+		if len(schemes) == 0 {
+			return parsed.String(), nil
+		}
+		for _, scheme := range schemes {
+			if strings.EqualFold(parsed.Scheme, scheme) {
+				return parsed.String(), nil
+			}
+		}
+		return nil, fmt.Errorf("value (%v) has scheme '%s' which is not one of allowed schemes: %v", input, parsed.Scheme, schemes)
+	}
+}
+
+//HostPortValidatorFactory creates validator for checking if the validator's given value is network address
+//in form "host:port" with numeric port. The validator returns normalized address (IPv6 hosts in brackets).
+func HostPortValidatorFactory() Validator {
+	return func(input interface{}) (interface{}, error) {
+		str, ok := input.(string)
+		if !ok {
+			return nil, fmt.Errorf("value (%v) is not host:port address", input)
+		}
+		host, port, err := net.SplitHostPort(strings.TrimSpace(str))
+		if err != nil {
+			return nil, fmt.Errorf("value (%v) is not host:port address", input)
+		}
+		if number, err := strconv.Atoi(port); err != nil || number < 0 || number > 65535 {
+			return nil, fmt.Errorf("value (%v) has invalid port '%s'", input, port)
+		}
+		return net.JoinHostPort(host, port), nil
+	}
+}
+
+func pathValidator(kind string, check func(os.FileInfo) bool) Validator {
+	return func(input interface{}) (interface{}, error) {
+		str, ok := input.(string)
+		if !ok || strings.TrimSpace(str) == "" {
+			return nil, fmt.Errorf("value (%v) is not path", input)
+		}
+		path := filepath.Clean(strings.TrimSpace(str))
+		info, err := os.Stat(path)
+		if err != nil {
+			return nil, fmt.Errorf("value (%v) is not existing %s: %s", input, kind, err)
+		}
+		if !check(info) {
+			return nil, fmt.Errorf("value (%v) is not %s", input, kind)
+		}
+		return path, nil
+	}
+}
+
+//FileValidatorFactory creates validator for checking if the validator's given value is path to existing
+//regular file. The validator returns cleaned path.
+func FileValidatorFactory() Validator {
+	return pathValidator("file", func(info os.FileInfo) bool { return info.Mode().IsRegular() })
+}
+
+//DirValidatorFactory creates validator for checking if the validator's given value is path to existing
+//directory. The validator returns cleaned path.
+func DirValidatorFactory() Validator {
+	return pathValidator("directory", func(info os.FileInfo) bool { return info.IsDir() })
+}
+
+//RegexValidatorFactory creates validator for checking if the validator's given value is valid regular expression.
+//The validator returns compiled *regexp.Regexp.
+func RegexValidatorFactory() Validator {
+	return func(input interface{}) (interface{}, error) {
+		switch val := input.(type) {
+		case *regexp.Regexp:
+			return val, nil
+		case string:
+			compiled, err := regexp.Compile(val)
+			if err != nil {
+				return nil, fmt.Errorf("value (%v) is not regular expression: %s", input, err)
+			}
+			return compiled, nil
+		default:
+			return nil, fmt.Errorf("value (%v) is not regular expression", input)
+		}
+	}
+}
+
+//PatternValidatorFactory creates validator for checking if the validator's given value matches given
+//regular expression.
+func PatternValidatorFactory(pattern *regexp.Regexp) Validator {
+	return func(input interface{}) (interface{}, error) {
+		if val, ok := input.(string); ok && pattern.MatchString(val) {
+			return val, nil
+		}
+		return nil, fmt.Errorf("value (%v) does not match pattern %s", input, pattern)
+	}
+}
+
+//IntRangeValidatorFactory creates validator for checking if the validator's given value is int
+//in range <min, max>. The validator returns int64.
+func IntRangeValidatorFactory(min, max int64) Validator {
+	return func(input interface{}) (interface{}, error) {
+		number, err := toInt64(input)
+		if err != nil {
+			return nil, err
+		}
+		if number < min || number > max {
+			return nil, fmt.Errorf("value (%v) is out of range <%d, %d>", input, min, max)
+		}
+		return number, nil
+	}
+}
+
+//FloatRangeValidatorFactory creates validator for checking if the validator's given value is float
+//in range <min, max>. The validator returns float64.
+func FloatRangeValidatorFactory(min, max float64) Validator {
+	return func(input interface{}) (interface{}, error) {
+		number, err := toFloat64(input)
+		if err != nil {
+			return nil, err
+		}
+		if number < min || number > max {
+			return nil, fmt.Errorf("value (%v) is out of range <%v, %v>", input, min, max)
+		}
+		return number, nil
+	}
+}
+
+//rangeValidator creates range validator from specification "min:max", where either of the bounds can be
+//omitted. Int range is used when both bounds are ints, float range otherwise.
+func rangeValidator(arg string) (Validator, error) {
+	bounds := strings.Split(arg, ":")
+	if len(bounds) != 2 || (bounds[0] == "" && bounds[1] == "") {
+		return nil, fmt.Errorf("range validator requires bounds in form min:max")
+	}
+	ints := [2]int64{math.MinInt64, math.MaxInt64}
+	floats := [2]float64{math.Inf(-1), math.Inf(1)}
+	isInt := true
+	for i, bound := range bounds {
+		if bound = strings.TrimSpace(bound); bound == "" {
+			continue
+		}
+		number, err := strconv.ParseFloat(bound, 64)
+		if err != nil {
+			return nil, fmt.Errorf("bound '%s' is not number", bound)
+		}
+		floats[i] = number
+		if ints[i], err = strconv.ParseInt(bound, 10, 64); err != nil {
+			isInt = false
+		}
+	}
+	if floats[0] > floats[1] {
+		return nil, fmt.Errorf("lower bound is greater than upper bound")
+	}
+	if isInt {
+		return IntRangeValidatorFactory(ints[0], ints[1]), nil
+	}
+	return FloatRangeValidatorFactory(floats[0], floats[1]), nil
+}
+
+//ValidatorFactory creates validator from argument given in validator specification, eg. "bar|baz"
+//in "options=bar|baz". Argument is empty when the specification has none.
+type ValidatorFactory func(arg string) (Validator, error)
+
+var (
+	validatorsLock sync.RWMutex
+	validators     = map[string]ValidatorFactory{
+		"bool": func(string) (Validator, error) { return BoolValidatorFactory(), nil },
+		"int":  func(string) (Validator, error) { return IntValidatorFactory(), nil },
+		"multiint": func(arg string) (Validator, error) {
+			if arg == "" {
+				arg = ","
+			}
+			return MultiIntValidatorFactory(arg), nil
+		},
+		"options": func(arg string) (Validator, error) {
+			if arg == "" {
+				return nil, fmt.Errorf("options validator requires list of options")
+			}
+			return StringOptionsValidatorFactory(strings.Split(arg, "|")), nil
+		},
+		"loglevel": func(string) (Validator, error) { return LogLevelValidatorFactory(), nil },
+		"duration": func(string) (Validator, error) { return DurationValidatorFactory(), nil },
+		"bytesize": func(string) (Validator, error) { return ByteSizeValidatorFactory(), nil },
+		"url": func(arg string) (Validator, error) {
+			schemes := []string{}
+			if arg != "" {
+				schemes = strings.Split(arg, "|")
+			}
+			return URLValidatorFactory(schemes), nil
+		},
+		"hostport": func(string) (Validator, error) { return HostPortValidatorFactory(), nil },
+		"file":     func(string) (Validator, error) { return FileValidatorFactory(), nil },
+		"dir":      func(string) (Validator, error) { return DirValidatorFactory(), nil },
+		"regex":    func(string) (Validator, error) { return RegexValidatorFactory(), nil },
+		"match": func(arg string) (Validator, error) {
+			pattern, err := regexp.Compile(arg)
+			if err != nil {
+				return nil, err
+			}
+			return PatternValidatorFactory(pattern), nil
+		},
+		"range": rangeValidator,
+	}
+)
+
+//RegisterValidator registers validator factory under given name for use in validator specifications
+//(eg. `validate:"name=arg"` struct tags). Builtin validators are registered as "bool", "int",
+//"multiint=<separator>", "options=<option>|<option>", "loglevel", "duration", "bytesize",
+//"url[=<scheme>|<scheme>]", "hostport", "file", "dir", "regex", "match=<pattern>" and "range=<min>:<max>".
+func RegisterValidator(name string, factory ValidatorFactory) {
+	validatorsLock.Lock()
+	defer validatorsLock.Unlock()
+	validators[name] = factory
+}
+
+//ParseValidators creates validators according to specification in form "name[=arg][,name[=arg]]"
+//using registered validator factories. Pattern of "match" validator can contain commas, so the rest
+//of the specification is taken as the pattern and the validator has to be the last one.
+func ParseValidators(spec string) ([]Validator, error) {
+	validatorsLock.RLock()
+	defer validatorsLock.RUnlock()
+
+	output := []Validator{}
+	for _, item := range splitValidatorSpec(spec) {
+		parts := strings.SplitN(item, "=", 2)
+		factory, ok := validators[parts[0]]
+		if !ok {
+			return nil, fmt.Errorf("unknown validator '%s'", parts[0])
+		}
+		arg := ""
+		if len(parts) > 1 {
+			arg = parts[1]
+		}
+		validator, err := factory(arg)
+		if err != nil {
+			return nil, fmt.Errorf("invalid validator '%s': %s", item, err)
+		}
+		output = append(output, validator)
+	}
+	return output, nil
+}
+
+//splitValidatorSpec splits validator specification to items, "match" validator takes rest of the specification
+func splitValidatorSpec(spec string) []string {
+	items := []string{}
+	for spec != "" {
+		item := spec
+		spec = ""
+		if index := strings.Index(item, ","); index >= 0 && !strings.HasPrefix(strings.TrimSpace(item), "match=") {
+			item, spec = item[:index], item[index+1:]
+		}
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
@@ -0,0 +1,286 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/infrawatch/apputils/logging"
+)
+
+//reloadable is implemented by config objects which can create empty copy of themselves with the same
+//metadata for parsing new version of the config file
+type reloadable interface {
+	Config
+	clone() Config
+	sections() map[string]*Section
+	files(path string) ([]string, error)
+}
+
+func (base *WithConfigBase) cloneBase() WithConfigBase {
+	clone := *base
+	clone.Sections = make(map[string]*Section)
+	clone.raw = make(map[string]map[string]interface{})
+	return clone
+}
+
+func (base *WithConfigBase) sections() map[string]*Section {
+	return base.Sections
+}
+
+func (conf *INIConfig) clone() Config {
+	return &INIConfig{WithConfigBase: conf.WithConfigBase.cloneBase()}
+}
+
+func (conf *INIConfig) files(path string) ([]string, error) {
+	return resolveFiles(path, formatExtensions["INI"], iniIncludes)
+}
+
+func (conf *documentConfig) files(path string) ([]string, error) {
+	return resolveFiles(path, formatExtensions[conf.format], func(file string) ([]string, error) {
+		_, _, patterns, err := conf.readDocument(file)
+		return patterns, err
+	})
+}
+
+func (conf *documentConfig) cloneDocument() documentConfig {
+	clone := *conf
+	clone.WithConfigBase = conf.WithConfigBase.cloneBase()
+	return clone
+}
+
+func (conf *JSONConfig) clone() Config {
+	return &JSONConfig{documentConfig: conf.cloneDocument()}
+}
+
+func (conf *YAMLConfig) clone() Config {
+	return &YAMLConfig{documentConfig: conf.cloneDocument()}
+}
+
+func (conf *TOMLConfig) clone() Config {
+	return &TOMLConfig{documentConfig: conf.cloneDocument()}
+}
+
+//Change describes option which changed value on config reload. Old is nil for added option,
+//New is nil for removed option.
+type Change struct {
+	Section string
+	Option  string
+	Old     *Option
+	New     *Option
+}
+
+//ChangeHandler is called with the new config and list of changed options after successful reload
+type ChangeHandler func(conf Config, changes []Change)
+
+//fileState holds attributes of watched file used to detect its modification
+type fileState struct {
+	modTime time.Time
+	size    int64
+}
+
+//Watcher reloads config file when it changes or when requested by signal. New version of the file
+//is parsed and validated into new config object, which replaces the current one only if the whole
+//file is valid, so invalid edit keeps the old configuration in use.
+type Watcher struct {
+	path     string
+	log      *logging.Logger
+	lock     sync.RWMutex
+	current  reloadable
+	state    map[string]fileState
+	handlers []ChangeHandler
+	stop     chan struct{}
+	done     chan struct{}
+}
+
+//NewWatcher creates watcher of the config file on given path. Given config has to be created by one
+//of the config constructors and should be already parsed, it is used as current config until
+//the first reload.
+func NewWatcher(conf Config, path string, logger *logging.Logger) (*Watcher, error) {
+	current, ok := conf.(reloadable)
+	if !ok {
+		return nil, fmt.Errorf("config of type %T does not support reloading", conf)
+	}
+	watcher := &Watcher{path: path, log: logger, current: current}
+	watcher.state = watcher.snapshot()
+	return watcher, nil
+}
+
+//Subscribe registers handler which is called after each successful reload which changed any option
+func (w *Watcher) Subscribe(handler ChangeHandler) {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+	w.handlers = append(w.handlers, handler)
+}
+
+//Current returns the latest valid config
+func (w *Watcher) Current() Config {
+	w.lock.RLock()
+	defer w.lock.RUnlock()
+	return w.current
+}
+
+//Reload parses the config file again. The current config is replaced and subscribers are notified
+//only if the new file is valid, otherwise error is returned and the current config is kept.
+func (w *Watcher) Reload() error {
+	w.lock.RLock()
+	next := w.current.clone().(reloadable)
+	w.lock.RUnlock()
+
+	if err := next.Parse(w.path); err != nil {
+		w.log.Error("failed to reload configuration, keeping the current one", logging.Metadata{
+			"path":  w.path,
+			"error": err,
+		})
+		return err
+	}
+
+	w.lock.Lock()
+	changes := diffSections(w.current.sections(), next.sections())
+	w.current = next
+	handlers := make([]ChangeHandler, len(w.handlers))
+	copy(handlers, w.handlers)
+	w.lock.Unlock()
+
+	w.log.Info("reloaded configuration", logging.Metadata{
+		"path":    w.path,
+		"changes": len(changes),
+	})
+	if len(changes) > 0 {
+		for _, handler := range handlers {
+			handler(next, changes)
+		}
+	}
+	return nil
+}
+
+//Start starts goroutine which reloads the config when modification time or size of any loaded file
+//(including included files and files of config directories) or of their directories changes, checked
+//every interval, and when any of given signals (usually SIGHUP) is received. Zero interval disables polling.
+func (w *Watcher) Start(interval time.Duration, signals ...os.Signal) {
+	w.stop = make(chan struct{})
+	w.done = make(chan struct{})
+
+	sigChannel := make(chan os.Signal, 1)
+	if len(signals) > 0 {
+		signal.Notify(sigChannel, signals...)
+	}
+
+	go func() {
+		defer close(w.done)
+		defer signal.Stop(sigChannel)
+		var tick <-chan time.Time
+		if interval > 0 {
+			ticker := time.NewTicker(interval)
+			defer ticker.Stop()
+			tick = ticker.C
+		}
+		for {
+			select {
+			case <-w.stop:
+				return
+			case <-tick:
+				if w.modified() {
+					w.Reload()
+				}
+			case sig := <-sigChannel:
+				w.log.Debug("reloading configuration on caught signal", logging.Metadata{"signal": sig})
+				w.modified()
+				w.Reload()
+			}
+		}
+	}()
+}
+
+//Stop stops watching started by Start
+func (w *Watcher) Stop() {
+	if w.stop != nil {
+		close(w.stop)
+		<-w.done
+		w.stop = nil
+	}
+}
+
+//modified returns true if any of the watched files changed since the last check
+func (w *Watcher) modified() bool {
+	if _, err := os.Stat(w.path); err != nil {
+		return false
+	}
+	state := w.snapshot()
+	if reflect.DeepEqual(state, w.state) {
+		return false
+	}
+	w.state = state
+	return true
+}
+
+//snapshot returns state of all files loaded from the config path and of directories containing them,
+//so that added and removed files are detected too. When the files cannot be resolved (eg. because
+//of invalid edit), the previously watched files are used.
+func (w *Watcher) snapshot() map[string]fileState {
+	w.lock.RLock()
+	files, err := w.current.files(w.path)
+	w.lock.RUnlock()
+	if err != nil {
+		files = make([]string, 0, len(w.state))
+		for file := range w.state {
+			files = append(files, file)
+		}
+	}
+
+	state := make(map[string]fileState)
+	for _, file := range append(files, w.path) {
+		for _, path := range []string{file, filepath.Dir(file)} {
+			if info, err := os.Stat(path); err == nil {
+				state[path] = fileState{modTime: info.ModTime(), size: info.Size()}
+			}
+		}
+	}
+	return state
+}
+
+func diffSections(old, new map[string]*Section) []Change {
+	changes := []Change{}
+	names := make(map[string]bool)
+	for name := range old {
+		names[name] = true
+	}
+	for name := range new {
+		names[name] = true
+	}
+	for name := range names {
+		oldOptions := make(map[string]*Option)
+		newOptions := make(map[string]*Option)
+		if section, ok := old[name]; ok {
+			oldOptions = section.Options
+		}
+		if section, ok := new[name]; ok {
+			newOptions = section.Options
+		}
+		for option, oldValue := range oldOptions {
+			newValue, ok := newOptions[option]
+			if !ok {
+				changes = append(changes, Change{Section: name, Option: option, Old: oldValue})
+			} else if !reflect.DeepEqual(oldValue.value, newValue.value) {
+				changes = append(changes, Change{Section: name, Option: option, Old: oldValue, New: newValue})
+			}
+		}
+		for option, newValue := range newOptions {
+			if _, ok := oldOptions[option]; !ok {
+				changes = append(changes, Change{Section: name, Option: option, New: newValue})
+			}
+		}
+	}
+	sort.Slice(changes, func(i, j int) bool {
+		if changes[i].Section != changes[j].Section {
+			return changes[i].Section < changes[j].Section
+		}
+		return changes[i].Option < changes[j].Option
+	})
+	return changes
+}
@@ -0,0 +1,18 @@
+package config
+
+import (
+	"github.com/infrawatch/apputils/logging"
+	"gopkg.in/yaml.v3"
+)
+
+//YAMLConfig holds complete configuration data and metadata for configuration saved as YAML file.
+//Names of flat parameters in the file are taken from yaml tags, json tags are used when yaml tag is missing.
+//Structured parameters and their fields are named as by the YAML decoder, ie. by yaml tags or by lowercased field names.
+type YAMLConfig struct {
+	documentConfig
+}
+
+//NewYAMLConfig creates and initializes new config object according to given metadata.
+func NewYAMLConfig(metadata map[string][]Parameter, logger *logging.Logger) *YAMLConfig {
+	return &YAMLConfig{documentConfig: newDocumentConfig(metadata, logger, "YAML", "yaml", yaml.Unmarshal, yaml.Marshal)}
+}
@@ -61,11 +61,10 @@ func CreateAMQP10Connector(logger *logging.Logger, address string, clientName st
 		if len(channel) < 1 {
 			continue
 		}
-		logger.Metadata(map[string]interface{}{
+		logger.Debug("Creating AMQP receiver for channel", logging.Metadata{
 			"channel":  channel,
 			"prefetch": listenPrefetch,
 		})
-		logger.Debug("Creating AMQP receiver for channel")
 		if err := connector.CreateReceiver(channel, int(listenPrefetch)); err != nil {
 			return &connector, fmt.Errorf("Failed to create receiver: %s", err)
 		}
@@ -73,19 +72,21 @@ func CreateAMQP10Connector(logger *logging.Logger, address string, clientName st
 	return &connector, nil
 }
 
+//amqp10Options maps options used by the connector to their locations in config
+var amqp10Options = config.Mapping{
+	"connection":      {INI: "amqp1.connection", Structured: "Amqp1.Connection.Address"},
+	"send_timeout":    {INI: "amqp1.send_timeout", Structured: "Amqp1.Connection.SendTimeout"},
+	"client_name":     {INI: "amqp1.client_name", Structured: "Amqp1.Client.Name"},
+	"listen_channels": {INI: "amqp1.listen_channels", Structured: "Amqp1.Connection.ListenChannels"},
+	"listen_prefetch": {INI: "amqp1.listen_prefetch", Structured: "Amqp1.Connection.ListenPrefetch"},
+}
+
 //ConnectAMQP10 creates new AMQP1.0 connector from the given configuration file
 func ConnectAMQP10(cfg config.Config, logger *logging.Logger) (*AMQP10Connector, error) {
 	var err error
 	var opt *config.Option
 
-	switch conf := cfg.(type) {
-	case *config.INIConfig:
-		opt, err = conf.GetOption("amqp1/connection")
-	case *config.JSONConfig:
-		opt, err = conf.GetOption("Amqp1.Connection.Address")
-	default:
-		return nil, fmt.Errorf("Unknown Config type")
-	}
+	opt, err = amqp10Options.Get(cfg, "connection")
 	if err != nil {
 		return nil, err
 	}
@@ -94,12 +95,7 @@ func ConnectAMQP10(cfg config.Config, logger *logging.Logger) (*AMQP10Connector,
 	}
 	addr := opt.GetString()
 
-	switch conf := cfg.(type) {
-	case *config.INIConfig:
-		opt, err = conf.GetOption("amqp1/send_timeout")
-	case *config.JSONConfig:
-		opt, err = conf.GetOption("Amqp1.Connection.SendTimeout")
-	}
+	opt, err = amqp10Options.Get(cfg, "send_timeout")
 	if err != nil {
 		return nil, err
 	}
@@ -108,12 +104,7 @@ func ConnectAMQP10(cfg config.Config, logger *logging.Logger) (*AMQP10Connector,
 		sendTimeout = opt.GetInt()
 	}
 
-	switch conf := cfg.(type) {
-	case *config.INIConfig:
-		opt, err = conf.GetOption("amqp1/client_name")
-	case *config.JSONConfig:
-		opt, err = conf.GetOption("Amqp1.Client.Name")
-	}
+	opt, err = amqp10Options.Get(cfg, "client_name")
 	if err != nil {
 		return nil, err
 	}
@@ -122,12 +113,7 @@ func ConnectAMQP10(cfg config.Config, logger *logging.Logger) (*AMQP10Connector,
 		clientName = opt.GetString()
 	}
 
-	switch conf := cfg.(type) {
-	case *config.INIConfig:
-		opt, err = conf.GetOption("amqp1/listen_channels")
-	case *config.JSONConfig:
-		opt, err = conf.GetOption("Amqp1.Connection.ListenChannels")
-	}
+	opt, err = amqp10Options.Get(cfg, "listen_channels")
 	if err != nil {
 		return nil, err
 	}
@@ -136,12 +122,7 @@ func ConnectAMQP10(cfg config.Config, logger *logging.Logger) (*AMQP10Connector,
 		listen = opt.GetStrings(",")
 	}
 
-	switch conf := cfg.(type) {
-	case *config.INIConfig:
-		opt, err = conf.GetOption("amqp1/listen_prefetch")
-	case *config.JSONConfig:
-		opt, err = conf.GetOption("Amqp1.Connection.ListenPrefetch")
-	}
+	opt, err = amqp10Options.Get(cfg, "listen_prefetch")
 	if err != nil {
 		return nil, err
 	}
@@ -157,21 +138,19 @@ func ConnectAMQP10(cfg config.Config, logger *logging.Logger) (*AMQP10Connector,
 func (conn *AMQP10Connector) Connect() error {
 	url, err := amqp.ParseURL(conn.Address)
 	if err != nil {
-		conn.logger.Metadata(map[string]interface{}{
+		conn.logger.Debug("Error while parsing AMQP1.0 URL", logging.Metadata{
 			"error":      err,
 			"connection": conn.Address,
 		})
-		conn.logger.Debug("Error while parsing AMQP1.0 URL")
 		return err
 	}
 
 	inContainer := electron.NewContainer(fmt.Sprintf("%s-infrawatch-in-%d", conn.ClientName, time.Now().Unix()))
 	cin, err := inContainer.Dial("tcp", url.Host)
 	if err != nil {
-		conn.logger.Metadata(map[string]interface{}{
+		conn.logger.Debug("AMQP dial TCP error", logging.Metadata{
 			"error": err,
 		})
-		conn.logger.Debug("AMQP dial TCP error")
 		return err
 	}
 	conn.inConnection = cin
@@ -179,10 +158,9 @@ func (conn *AMQP10Connector) Connect() error {
 	outContainer := electron.NewContainer(fmt.Sprintf("%s-infrawatch-out-%d", conn.ClientName, time.Now().Unix()))
 	cout, err := outContainer.Dial("tcp", url.Host)
 	if err != nil {
-		conn.logger.Metadata(map[string]interface{}{
+		conn.logger.Debug("AMQP dial TCP error", logging.Metadata{
 			"error": err,
 		})
-		conn.logger.Debug("AMQP dial TCP error")
 		return err
 	}
 	conn.outConnection = cout
@@ -197,11 +175,10 @@ func (conn *AMQP10Connector) CreateReceiver(address string, prefetch int) error
 
 	opts := []electron.LinkOption{electron.Source(parts[0])}
 	if prefetch > 0 {
-		conn.logger.Metadata(map[string]interface{}{
+		conn.logger.Debug("Setting prefetch for address", logging.Metadata{
 			"address":  address,
 			"prefetch": prefetch,
 		})
-		conn.logger.Debug("Setting prefetch for address")
 		opts = append(opts, electron.Capacity(prefetch), electron.Prefetch(true))
 	}
 
@@ -211,11 +188,10 @@ func (conn *AMQP10Connector) CreateReceiver(address string, prefetch int) error
 	if rcv, err := conn.inConnection.Receiver(opts...); err == nil {
 		conn.receivers = append(conn.receivers, AMQP10Receiver{rcv, parts[1:]})
 	} else {
-		conn.logger.Metadata(map[string]interface{}{
+		conn.logger.Debug("Failed to create receiver for given address", logging.Metadata{
 			"address": address,
 			"error":   err,
 		})
-		conn.logger.Debug("Failed to create receiver for given address")
 		return err
 	}
 	return nil
@@ -231,11 +207,10 @@ func (conn *AMQP10Connector) Reconnect() error {
 func (conn *AMQP10Connector) Disconnect() {
 	conn.inConnection.Close(nil)
 	conn.outConnection.Close(nil)
-	conn.logger.Metadata(map[string]interface{}{
+	conn.logger.Debug("Closed connections", logging.Metadata{
 		"incoming": conn.inConnection,
 		"outgoing": conn.outConnection,
 	})
-	conn.logger.Debug("Closed connections")
 }
 
 func (conn *AMQP10Connector) processIncomingMessage(msg interface{}, outchan chan interface{}, receiver AMQP10Receiver) {
@@ -253,10 +228,9 @@ func (conn *AMQP10Connector) processIncomingMessage(msg interface{}, outchan cha
 		message.Body = typedBody
 		outchan <- message
 	default:
-		conn.logger.Metadata(map[string]interface{}{
+		conn.logger.Debug("Skipped processing of received AMQP1.0 message with invalid type", logging.Metadata{
 			"message": typedBody,
 		})
-		conn.logger.Debug("Skipped processing of received AMQP1.0 message with invalid type")
 		outchan <- message
 	}
 }
@@ -273,20 +247,18 @@ func (conn *AMQP10Connector) Start(outchan chan interface{}, inchan chan interfa
 					conn.processIncomingMessage(msg.Message.Body(), outchan, receiver)
 					conn.logger.Debug("Message ACKed")
 				} else if err == electron.Closed {
-					conn.logger.Metadata(map[string]interface{}{
+					conn.logger.Warn("Channel closed, closing receiver loop", logging.Metadata{
 						"connection": conn.Address,
 						"address":    receiver.Receiver.Source(),
 					})
-					conn.logger.Warn("Channel closed, closing receiver loop")
 					//TODO: send message to (future) reconnect loop, where it Reconnect and Start again
 					return
 				} else {
-					conn.logger.Metadata(map[string]interface{}{
+					conn.logger.Error("Received AMQP1.0 error", logging.Metadata{
 						"connection": conn.Address,
 						"address":    receiver.Receiver.Source(),
 						"error":      err,
 					})
-					conn.logger.Error("Received AMQP1.0 error")
 				}
 			}
 		}(rcv)
@@ -299,19 +271,17 @@ func (conn *AMQP10Connector) Start(outchan chan interface{}, inchan chan interfa
 			case AMQP10Message:
 				sender, err := conn.outConnection.Sender(electron.Target(message.Address))
 				if err != nil {
-					conn.logger.Metadata(map[string]interface{}{
+					conn.logger.Warn("Failed to create AMQP1.0 sender on given connection, skipping processing message", logging.Metadata{
 						"connection": conn.Address,
 						"message":    message,
 						"error":      err,
 					})
-					conn.logger.Warn("Failed to create AMQP1.0 sender on given connection, skipping processing message")
 					continue
 				}
-				conn.logger.Metadata(map[string]interface{}{
+				conn.logger.Debug("Sending AMQP1.0 message", logging.Metadata{
 					"address": message.Address,
 					"body":    message.Body,
 				})
-				conn.logger.Debug("Sending AMQP1.0 message")
 
 				m := amqp.NewMessage()
 				m.SetContentType("application/json")
@@ -323,23 +293,20 @@ func (conn *AMQP10Connector) Start(outchan chan interface{}, inchan chan interfa
 				select {
 				case ack := <-ackChan:
 					if ack.Status != 2 {
-						conn.logger.Metadata(map[string]interface{}{
+						conn.logger.Warn("Sent message was not ACKed", logging.Metadata{
 							"message": m,
 							"ack":     ack,
 						})
-						conn.logger.Warn("Sent message was not ACKed")
 					}
 				case <-timer.C:
-					conn.logger.Metadata(map[string]interface{}{
+					conn.logger.Warn("Sent message timed out on ACK. Delivery not guaranteed.", logging.Metadata{
 						"message": m,
 					})
-					conn.logger.Warn("Sent message timed out on ACK. Delivery not guaranteed.")
 				}
 			default:
-				conn.logger.Metadata(map[string]interface{}{
+				conn.logger.Debug("Skipped processing of sent AMQP1.0 message with invalid type", logging.Metadata{
 					"message": msg,
 				})
-				conn.logger.Debug("Skipped processing of sent AMQP1.0 message with invalid type")
 			}
 		}
 	}()
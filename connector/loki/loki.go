@@ -62,8 +62,12 @@ type LokiConnector struct {
 	batchCounter   int64
 	timer          *time.Timer
 	logger         *logging.Logger
+	httpClient     *http.Client
 }
 
+//requestTimeout limits duration of requests to Loki, so that unresponsive server can't block sending forever
+const requestTimeout = 10 * time.Second
+
 //Message hold structure for Loki API messages
 type Message struct {
 	Message string
@@ -78,7 +82,7 @@ type endpoints struct {
 
 //IsReady checks if the loki is ready
 func (client *LokiConnector) IsReady() bool {
-	response, err := http.Get(client.url + client.endpoints.ready)
+	response, err := client.httpClient.Get(client.url + client.endpoints.ready)
 	return err == nil && response.StatusCode == 200
 }
 
@@ -90,6 +94,7 @@ func CreateLokiConnector(logger *logging.Logger, address string, maxWaitTime tim
 		quit:        make(chan struct{}),
 		streams:     make(chan *LokiStream),
 		logger:      logger,
+		httpClient:  &http.Client{Timeout: requestTimeout},
 		endpoints:   endpoints{
 			push:  "/loki/api/v1/push",
 			query: "/loki/api/v1/query_range",
@@ -100,6 +105,13 @@ func CreateLokiConnector(logger *logging.Logger, address string, maxWaitTime tim
 	return &client, err
 }
 
+//lokiOptions maps options used by the connector to their locations in config
+var lokiOptions = config.Mapping{
+	"connection":    {INI: "loki.connection", Structured: "Loki.Connection.Address"},
+	"batch_size":    {INI: "loki.batch_size", Structured: "Loki.Connection.BatchSize"},
+	"max_wait_time": {INI: "loki.max_wait_time", Structured: "Loki.Connection.MaxWaitTime"},
+}
+
 //ConnectLoki creates a new loki connector
 func ConnectLoki(cfg config.Config, logger *logging.Logger) (*LokiConnector, error) {
 	var err error
@@ -108,14 +120,7 @@ func ConnectLoki(cfg config.Config, logger *logging.Logger) (*LokiConnector, err
 	var maxWaitTime time.Duration
 
 	var addr *config.Option
-	switch conf := cfg.(type) {
-	case *config.INIConfig:
-		addr, err = conf.GetOption("loki/connection")
-	case *config.JSONConfig:
-		addr, err = conf.GetOption("Loki.Connection.Address")
-	default:
-		return nil, fmt.Errorf("Unknown Config type")
-	}
+	addr, err = lokiOptions.Get(cfg, "connection")
 	if err == nil && addr != nil {
 		url = addr.GetString()
 	} else {
@@ -123,12 +128,7 @@ func ConnectLoki(cfg config.Config, logger *logging.Logger) (*LokiConnector, err
 	}
 
 	var batchSize *config.Option
-	switch conf := cfg.(type) {
-	case *config.INIConfig:
-		batchSize, err = conf.GetOption("loki/batch_size")
-	case *config.JSONConfig:
-		batchSize, err = conf.GetOption("Loki.Connection.BatchSize")
-	}
+	batchSize, err = lokiOptions.Get(cfg, "batch_size")
 	if err == nil && batchSize != nil {
 		maxBatch = batchSize.GetInt()
 	} else {
@@ -136,12 +136,7 @@ func ConnectLoki(cfg config.Config, logger *logging.Logger) (*LokiConnector, err
 	}
 
 	var waitTime *config.Option
-	switch conf := cfg.(type) {
-	case *config.INIConfig:
-		waitTime, err = conf.GetOption("loki/max_wait_time")
-	case *config.JSONConfig:
-		waitTime, err = conf.GetOption("Loki.Connection.MaxWaitTime")
-	}
+	waitTime, err = lokiOptions.Get(cfg, "max_wait_time")
 	if err == nil && waitTime != nil {
 		maxWaitTime = time.Duration(waitTime.GetInt()) * time.Millisecond
 	} else {
@@ -168,7 +163,7 @@ func (client *LokiConnector) Disconnect() {
 }
 
 //Start a goroutine, which sends data to loki if the current batch > maxBatch or if more time
-//than maxWaitTime passed
+//than maxWaitTime passed. Logs already waiting in inchan are processed on Disconnect.
 func (client *LokiConnector) Start(outchan chan interface{}, inchan chan interface{}) {
 	client.wait.Add(1)
 	go func() {
@@ -183,30 +178,22 @@ func (client *LokiConnector) Start(outchan chan interface{}, inchan chan interfa
 		for {
 			select {
 			case <-client.quit:
-				return
-			case logs := <-inchan:
-				switch message := logs.(type) {
-				case LokiStream:
-					client.addStream(message)
-				case LokiLog:
-					m := Message{
-						Message: message.LogMessage,
-						Time:    message.Timestamp,
+				// drain buffered input, so that the last batch contains everything written before disconnect
+				for {
+					select {
+					case logs := <-inchan:
+						client.process(logs)
+					default:
+						return
 					}
-					stream := client.CreateStream(message.Labels, []Message{m})
-					client.addStream(stream)
-				default:
-					client.logger.Metadata(map[string]interface{}{
-						"logs": logs,
-					})
-					client.logger.Info("Skipped processing of received log stream of invalid format")
 				}
+			case logs := <-inchan:
+				client.process(logs)
 			case <-client.timer.C:
 				if client.batchCounter > 0 {
-					client.logger.Metadata(map[string]interface{}{
+					client.logger.Debug("Sending logs, cause: time == maxWaitTime", logging.Metadata{
 						"logStreams": client.currentMessage.Streams,
 					})
-					client.logger.Debug("Sending logs, cause: time == maxWaitTime")
 					client.send()
 				} else {
 					client.timer.Reset(client.maxWaitTime)
@@ -216,14 +203,31 @@ func (client *LokiConnector) Start(outchan chan interface{}, inchan chan interfa
 	}()
 }
 
+func (client *LokiConnector) process(logs interface{}) {
+	switch message := logs.(type) {
+	case LokiStream:
+		client.addStream(message)
+	case LokiLog:
+		m := Message{
+			Message: message.LogMessage,
+			Time:    message.Timestamp,
+		}
+		stream := client.CreateStream(message.Labels, []Message{m})
+		client.addStream(stream)
+	default:
+		client.logger.Info("Skipped processing of received log stream of invalid format", logging.Metadata{
+			"logs": logs,
+		})
+	}
+}
+
 func (client *LokiConnector) addStream(stream LokiStream) {
 	client.currentMessage.Streams = append(client.currentMessage.Streams, stream)
 	client.batchCounter++
 	if client.batchCounter == client.maxBatch {
-		client.logger.Metadata(map[string]interface{}{
+		client.logger.Debug("Sending logs, cause: batchCounter == maxBatch", logging.Metadata{
 			"logStreams": client.currentMessage.Streams,
 		})
-		client.logger.Debug("Sending logs, cause: batchCounter == maxBatch")
 		client.send()
 	}
 }
@@ -252,30 +256,27 @@ func (client *LokiConnector) send() (*http.Response, error) {
 		return nil, err
 	}
 
-	response, err := http.Post(client.url+client.endpoints.push, "application/json", bytes.NewReader(str))
+	response, err := client.httpClient.Post(client.url+client.endpoints.push, "application/json", bytes.NewReader(str))
 
 	client.batchCounter = 0
 	client.currentMessage.Streams = []LokiStream{}
 	client.timer.Reset(client.maxWaitTime)
 
 	if err != nil {
-		client.logger.Metadata(map[string]interface{}{
+		client.logger.Error("An error occured when trying to send logs", logging.Metadata{
 			"error": err,
 		})
-		client.logger.Error("An error occured when trying to send logs")
 		return nil, err
 	} else if response.StatusCode != 204 {
-		client.logger.Metadata(map[string]interface{}{
+		client.logger.Error("Recieved unexpected statuscode when trying to send logs", logging.Metadata{
 			"error":    err,
 			"response": response,
 		})
-		client.logger.Error("Recieved unexpected statuscode when trying to send logs")
 		return nil, fmt.Errorf("Got %d http status code after pushing to loki instead of expected 204", response.StatusCode)
 	} else {
-		client.logger.Metadata(map[string]interface{}{
+		client.logger.Debug("Logs successfuly sent", logging.Metadata{
 			"response": response,
 		})
-		client.logger.Debug("Logs successfuly sent")
 		return response, nil
 	}
 }
@@ -307,20 +308,18 @@ func (client *LokiConnector) Query(queryString string, startTime time.Duration,
 	params.Add("limit", strconv.Itoa(limit))
 	url := client.url + client.endpoints.query + "?" + params.Encode()
 
-	client.logger.Metadata(map[string]interface{}{
+	client.logger.Debug("Sending query to Loki", logging.Metadata{
 		"url": url,
 	})
-	client.logger.Debug("Sending query to Loki")
 
-	response, err := http.Get(url)
+	response, err := client.httpClient.Get(url)
 	if err != nil {
 		return []Message{}, err
 	}
 
-	client.logger.Metadata(map[string]interface{}{
+	client.logger.Debug("Recieved answer from loki", logging.Metadata{
 		"response": response,
 	})
-	client.logger.Debug("Recieved answer from loki")
 
 	body, err := ioutil.ReadAll(response.Body)
 	if err != nil {
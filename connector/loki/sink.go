@@ -0,0 +1,121 @@
+package loki
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/infrawatch/apputils/logging"
+)
+
+//SinkBufferSize is count of records LoggerSink buffers for the connector, records written when
+//the buffer is full (eg. when Loki is slow or unreachable) are dropped
+const SinkBufferSize = 1024
+
+//LoggerSink ships records of logging.Logger to Loki through LokiConnector
+type LoggerSink struct {
+	client    *LokiConnector
+	labels    map[string]string
+	labelKeys map[string]bool
+	input     chan interface{}
+	dropped   int64
+	lock      sync.RWMutex
+	closed    bool
+}
+
+//NewLoggerSink creates a sink for logging.Logger which sends every record to Loki. The sink starts
+// the given connector and disconnects it on Close, so the connector should not be used for anything
+// else. Note that the connector must not log using the logger the sink is attached to.
+//
+// Records are passed to the connector through buffer of SinkBufferSize records, so that logging
+// is not blocked by sending to Loki. Records which don't fit in the buffer are dropped and counted.
+//
+// Every stream gets the given labels together with "level" label holding the record level.
+// Metadata keys listed in labelKeys are turned into labels as well, the rest of the metadata
+// is appended to the log line as structured key=value fields.
+func NewLoggerSink(client *LokiConnector, labels map[string]string, labelKeys ...string) *LoggerSink {
+	sink := LoggerSink{
+		client:    client,
+		labels:    labels,
+		labelKeys: make(map[string]bool),
+		input:     make(chan interface{}, SinkBufferSize),
+	}
+	for _, key := range labelKeys {
+		sink.labelKeys[key] = true
+	}
+	client.Start(nil, sink.input)
+	return &sink
+}
+
+//Write converts the record to LokiLog and passes it to the connector. The record is dropped when
+//the buffer is full, error is returned when the sink is closed.
+func (sink *LoggerSink) Write(record logging.Record) error {
+	labels := make(map[string]string)
+	for key, value := range sink.labels {
+		labels[key] = value
+	}
+	labels["level"] = record.Level.String()
+
+	fields := make([]string, 0, len(record.Metadata))
+	for key, value := range record.Metadata {
+		if sink.labelKeys[key] {
+			labels[key] = fmt.Sprintf("%v", value)
+		} else {
+			fields = append(fields, key)
+		}
+	}
+	sort.Strings(fields)
+
+	var line bytes.Buffer
+	line.WriteString(record.Message)
+	for _, key := range fields {
+		fmt.Fprintf(&line, " %s=%q", key, fmt.Sprintf("%v", record.Metadata[key]))
+	}
+	if record.Caller != "" {
+		fmt.Fprintf(&line, " caller=%q function=%q", record.Caller, record.Function)
+	}
+	if record.Stack != "" {
+		fmt.Fprintf(&line, " stack=%q", record.Stack)
+	}
+
+	timestamp := record.Time
+	if timestamp.IsZero() {
+		timestamp = time.Now()
+	}
+
+	sink.lock.RLock()
+	defer sink.lock.RUnlock()
+	if sink.closed {
+		return fmt.Errorf("loki sink is closed")
+	}
+	select {
+	case sink.input <- LokiLog{
+		LogMessage: line.String(),
+		Timestamp:  time.Duration(timestamp.UnixNano()),
+		Labels:     labels,
+	}:
+	default:
+		atomic.AddInt64(&sink.dropped, 1)
+	}
+	return nil
+}
+
+//Dropped returns count of records dropped because the buffer was full
+func (sink *LoggerSink) Dropped() int64 {
+	return atomic.LoadInt64(&sink.dropped)
+}
+
+//Close sends the last batch to Loki and stops the connector
+func (sink *LoggerSink) Close() error {
+	sink.lock.Lock()
+	defer sink.lock.Unlock()
+	if sink.closed {
+		return fmt.Errorf("loki sink is already closed")
+	}
+	sink.closed = true
+	sink.client.Disconnect()
+	return nil
+}
@@ -96,19 +96,21 @@ func CreateSensuConnector(logger *logging.Logger, address string, clientName str
 	return &connector, nil
 }
 
+//sensuOptions maps options used by the connector to their locations in config
+var sensuOptions = config.Mapping{
+	"connection":         {INI: "sensu.connection", Structured: "Sensu.Connection.Address"},
+	"subscriptions":      {INI: "sensu.subscriptions", Structured: "Sensu.Connection.Subscriptions"},
+	"client_name":        {INI: "sensu.client_name", Structured: "Sensu.Client.Name"},
+	"client_address":     {INI: "sensu.client_address", Structured: "Sensu.Client.Address"},
+	"keepalive_interval": {INI: "sensu.keepalive_interval", Structured: "Sensu.Connection.KeepaliveInterval"},
+}
+
 //ConnectSensu creates new Sensu connector from the given configuration file
 func ConnectSensu(cfg config.Config, logger *logging.Logger) (*SensuConnector, error) {
 	var err error
 	var opt *config.Option
 
-	switch conf := cfg.(type) {
-	case *config.INIConfig:
-		opt, err = conf.GetOption("sensu/connection")
-	case *config.JSONConfig:
-		opt, err = conf.GetOption("Sensu.Connection.Address")
-	default:
-		return nil, fmt.Errorf("Unknown Config type")
-	}
+	opt, err = sensuOptions.Get(cfg, "connection")
 	if err != nil {
 		return nil, err
 	}
@@ -117,12 +119,7 @@ func ConnectSensu(cfg config.Config, logger *logging.Logger) (*SensuConnector, e
 	}
 	addr := opt.GetString()
 
-	switch conf := cfg.(type) {
-	case *config.INIConfig:
-		opt, err = conf.GetOption("sensu/subscriptions")
-	case *config.JSONConfig:
-		opt, err = conf.GetOption("Sensu.Connection.Subscriptions")
-	}
+	opt, err = sensuOptions.Get(cfg, "subscriptions")
 	if err != nil {
 		return nil, err
 	}
@@ -131,12 +128,7 @@ func ConnectSensu(cfg config.Config, logger *logging.Logger) (*SensuConnector, e
 		subs = opt.GetStrings(",")
 	}
 
-	switch conf := cfg.(type) {
-	case *config.INIConfig:
-		opt, err = conf.GetOption("sensu/client_name")
-	case *config.JSONConfig:
-		opt, err = conf.GetOption("Sensu.Client.Name")
-	}
+	opt, err = sensuOptions.Get(cfg, "client_name")
 	if err != nil {
 		return nil, err
 	}
@@ -145,12 +137,7 @@ func ConnectSensu(cfg config.Config, logger *logging.Logger) (*SensuConnector, e
 		clientName = opt.GetString()
 	}
 
-	switch conf := cfg.(type) {
-	case *config.INIConfig:
-		opt, err = conf.GetOption("sensu/client_address")
-	case *config.JSONConfig:
-		opt, err = conf.GetOption("Sensu.Client.Address")
-	}
+	opt, err = sensuOptions.Get(cfg, "client_address")
 	if err != nil {
 		return nil, err
 	}
@@ -159,12 +146,7 @@ func ConnectSensu(cfg config.Config, logger *logging.Logger) (*SensuConnector, e
 		clientAddr = opt.GetString()
 	}
 
-	switch conf := cfg.(type) {
-	case *config.INIConfig:
-		opt, err = conf.GetOption("sensu/keepalive_interval")
-	case *config.JSONConfig:
-		opt, err = conf.GetOption("Sensu.Connection.KeepaliveInterval")
-	}
+	opt, err = sensuOptions.Get(cfg, "keepalive_interval")
 	if err != nil {
 		return nil, err
 	}
@@ -252,8 +234,7 @@ func (conn *SensuConnector) Connect() error {
 		)
 		if err != nil {
 			failed = append(failed, err.Error())
-			conn.logger.Metadata(logging.Metadata{"subscription": sub, "error": err})
-			conn.logger.Warn("Failed to subscribe.")
+			conn.logger.Warn("Failed to subscribe.", logging.Metadata{"subscription": sub, "error": err})
 		}
 	}
 	if len(failed) == len(conn.Subscription) {
@@ -290,8 +271,7 @@ func (conn *SensuConnector) Start(outchan chan interface{}, inchan chan interfac
 			if err == nil {
 				outchan <- request
 			} else {
-				conn.logger.Metadata(logging.Metadata{"error": err, "request-body": req.Body})
-				conn.logger.Warn("Failed to unmarshal request body.")
+				conn.logger.Warn("Failed to unmarshal request body.", logging.Metadata{"error": err, "request-body": req.Body})
 			}
 		}
 	}()
@@ -303,8 +283,7 @@ func (conn *SensuConnector) Start(outchan chan interface{}, inchan chan interfac
 			case CheckResult:
 				body, err := json.Marshal(result)
 				if err != nil {
-					conn.logger.Metadata(logging.Metadata{"error": err})
-					conn.logger.Error("Failed to marshal execution result.")
+					conn.logger.Error("Failed to marshal execution result.", logging.Metadata{"error": err})
 					continue
 				}
 				err = conn.outChannel.Publish(
@@ -321,12 +300,10 @@ func (conn *SensuConnector) Start(outchan chan interface{}, inchan chan interfac
 						Priority:        0,              // 0-9
 					})
 				if err != nil {
-					conn.logger.Metadata(logging.Metadata{"error": err})
-					conn.logger.Error("Failed to publish execution result.")
+					conn.logger.Error("Failed to publish execution result.", logging.Metadata{"error": err})
 				}
 			default:
-				conn.logger.Metadata(logging.Metadata{"type": fmt.Sprintf("%T", res)})
-				conn.logger.Debug("Received execution result with invalid type.")
+				conn.logger.Debug("Received execution result with invalid type.", logging.Metadata{"type": fmt.Sprintf("%T", res)})
 			}
 		}
 	}()
@@ -342,8 +319,7 @@ func (conn *SensuConnector) Start(outchan chan interface{}, inchan chan interfac
 				Timestamp:    time.Now().Unix(),
 			})
 			if err != nil {
-				conn.logger.Metadata(logging.Metadata{"error": err})
-				conn.logger.Error("Failed to marshal keepalive body.")
+				conn.logger.Error("Failed to marshal keepalive body.", logging.Metadata{"error": err})
 				continue
 			}
 			err = conn.outChannel.Publish(
@@ -360,8 +336,7 @@ func (conn *SensuConnector) Start(outchan chan interface{}, inchan chan interfac
 					Priority:        0,              // 0-9
 				})
 			if err != nil {
-				conn.logger.Metadata(logging.Metadata{"error": err})
-				conn.logger.Error("Failed to publish keepalive body.")
+				conn.logger.Error("Failed to publish keepalive body.", logging.Metadata{"error": err})
 			}
 			time.Sleep(time.Duration(conn.KeepaliveInterval) * time.Second)
 		}
@@ -39,10 +39,9 @@ func CreateUnixSocketConnector(logger *logging.Logger, inAddress string, outAddr
 	if inAddress != "" {
 		connector.in.Address.Name = inAddress
 		connector.in.Address.Net = "unixgram"
-		connector.logger.Metadata(map[string]interface{}{
+		connector.logger.Debug("In socket configured", logging.Metadata{
 			"address": connector.in.Address.Name,
 		})
-		connector.logger.Debug("In socket configured")
 	} else {
 		connector.in = nil
 		connector.logger.Debug("The in socket isn't configured")
@@ -52,10 +51,9 @@ func CreateUnixSocketConnector(logger *logging.Logger, inAddress string, outAddr
 		connector.out.Address.Name = outAddress
 		connector.out.Address.Net = "unixgram"
 
-		connector.logger.Metadata(map[string]interface{}{
+		connector.logger.Debug("Out socket configured", logging.Metadata{
 			"address": connector.out.Address.Name,
 		})
-		connector.logger.Debug("Out socket configured")
 	} else {
 		connector.out = nil
 		connector.logger.Debug("The out socket isn't configured")
@@ -65,18 +63,19 @@ func CreateUnixSocketConnector(logger *logging.Logger, inAddress string, outAddr
 	return &connector, err
 }
 
+//socketOptions maps options used by the connector to their locations in config
+var socketOptions = config.Mapping{
+	"in_address":  {INI: "socket.in_address", Structured: "Socket.In.Address"},
+	"out_address": {INI: "socket.out_address", Structured: "Socket.Out.Address"},
+}
+
 //ConnectUnixSocket ...
 func ConnectUnixSocket(cfg config.Config, logger *logging.Logger) (*UnixSocketConnector, error) {
 	var err error
 	var inAddress, outAddress string
 
 	var inAddr *config.Option
-	switch conf := cfg.(type) {
-	case *config.INIConfig:
-		inAddr, err = conf.GetOption("socket/in_address")
-	case *config.JSONConfig:
-		inAddr, err = conf.GetOption("Socket.In.Address")
-	}
+	inAddr, err = socketOptions.Get(cfg, "in_address")
 
 	if err == nil && inAddr != nil {
 		inAddress = inAddr.GetString()
@@ -85,14 +84,9 @@ func ConnectUnixSocket(cfg config.Config, logger *logging.Logger) (*UnixSocketCo
 	}
 
 	var outAddr *config.Option
-	switch conf := cfg.(type) {
-	case *config.INIConfig:
-		outAddr, err = conf.GetOption("socket/out_address")
-	case *config.JSONConfig:
-		outAddr, err = conf.GetOption("Socket.Out.Address")
-	}
+	outAddr, err = socketOptions.Get(cfg, "out_address")
 
-	if err == nil && inAddr != nil {
+	if err == nil && outAddr != nil {
 		outAddress = outAddr.GetString()
 	} else {
 		outAddress = ""
@@ -116,10 +110,9 @@ func (connector *UnixSocketConnector) connectSingleSocket(info *socketInfo, isIn
 	if err != nil {
 		return err
 	}
-	connector.logger.Metadata(map[string]interface{}{
+	connector.logger.Debug("Connected to unix socket", logging.Metadata{
 		"address": info.Address.Name,
 	})
-	connector.logger.Debug("Connected to unix socket")
 	return nil
 }
 
@@ -150,19 +143,17 @@ func (connector *UnixSocketConnector) Start(outchan chan interface{}, inchan cha
 			for {
 				n, err := connector.in.Pc.Read(connector.msgBuffer[:])
 				if err != nil || n < 1 {
-					connector.logger.Metadata(map[string]interface{}{
+					connector.logger.Debug("Error while trying to read from unix socket.", logging.Metadata{
 						"error":           err,
 						"characters read": n,
 					})
-					connector.logger.Debug("Error while trying to read from unix socket.")
 					continue
 				}
 				msg := string(connector.msgBuffer[:n])
 				outchan <- msg
-				connector.logger.Metadata(map[string]interface{}{
+				connector.logger.Debug("Recieved a message.", logging.Metadata{
 					"message": msg,
 				})
-				connector.logger.Debug("Recieved a message.")
 			}
 		}()
 	}
@@ -175,22 +166,19 @@ func (connector *UnixSocketConnector) Start(outchan chan interface{}, inchan cha
 				case string:
 					n, err := connector.out.Pc.Write([]byte(message))
 					if err != nil || n < 1 {
-						connector.logger.Metadata(map[string]interface{}{
+						connector.logger.Debug("Error while trying to write to unix socket.", logging.Metadata{
 							"error":              err,
 							"characters written": n,
 						})
-						connector.logger.Debug("Error while trying to write to unix socket.")
 						continue
 					}
-					connector.logger.Metadata(map[string]interface{}{
+					connector.logger.Debug("Sent a message.", logging.Metadata{
 						"message": message,
 					})
-					connector.logger.Debug("Sent a message.")
 				default:
-					connector.logger.Metadata(map[string]interface{}{
+					connector.logger.Debug("Skipped processing of sent message with invalid type", logging.Metadata{
 						"message": msg,
 					})
-					connector.logger.Debug("Skipped processing of sent message with invalid type")
 				}
 			}
 		}()
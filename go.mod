@@ -3,8 +3,10 @@ module github.com/infrawatch/apputils
 go 1.13
 
 require (
+	github.com/BurntSushi/toml v1.2.1
 	github.com/apache/qpid-proton v0.0.0-20201123182747-7735f1b7b39b
 	github.com/go-ini/ini v1.62.0
 	github.com/streadway/amqp v1.0.0
 	github.com/stretchr/testify v1.6.1
+	gopkg.in/yaml.v3 v3.0.1
 )
@@ -0,0 +1,160 @@
+package logging
+
+import (
+	"fmt"
+	"sync"
+	"sync/atomic"
+)
+
+// OverflowPolicy defines behaviour of asynchronous logger when its buffer is full
+type OverflowPolicy int
+
+const (
+	// OverflowBlock makes the logging call wait for free space in the buffer
+	OverflowBlock OverflowPolicy = iota
+	// OverflowDropNewest drops the record being logged
+	OverflowDropNewest
+	// OverflowDropOldest drops the oldest record in the buffer to make space for the new one
+	OverflowDropOldest
+)
+
+type asyncItem struct {
+	record  Record
+	flushed chan struct{}
+}
+
+// asyncWriter passes records to sinks from a separate goroutine
+type asyncWriter struct {
+	queue   chan asyncItem
+	policy  OverflowPolicy
+	dropped int64
+	// lock guards queue closing against concurrent sends
+	lock   sync.RWMutex
+	closed bool
+	done   chan struct{}
+}
+
+// SetAsync switches the logger to asynchronous mode in which records are buffered and written
+// to sinks by a separate goroutine, so that logging calls don't wait for slow targets. Given policy
+// decides what happens when the buffer of bufferSize records is full, dropped records are reported
+// by a WARN record. Zero bufferSize switches the logger back to synchronous mode after all pending
+// records are written. Destroy drains the buffer too.
+func (l *Logger) SetAsync(bufferSize int, policy OverflowPolicy) {
+	l.core.lock.Lock()
+	old := l.core.async
+	l.core.async = nil
+	l.core.lock.Unlock()
+	if old != nil {
+		old.shutdown()
+	}
+
+	if bufferSize <= 0 {
+		return
+	}
+	async := &asyncWriter{
+		queue:  make(chan asyncItem, bufferSize),
+		policy: policy,
+		done:   make(chan struct{}),
+	}
+	go async.run(l.core)
+	l.core.lock.Lock()
+	l.core.async = async
+	l.core.lock.Unlock()
+}
+
+// Flush waits until all records buffered in asynchronous mode are written
+func (l *Logger) Flush() {
+	l.core.lock.Lock()
+	async := l.core.async
+	l.core.lock.Unlock()
+	if async != nil {
+		async.flush()
+	}
+}
+
+func (a *asyncWriter) run(core *loggerCore) {
+	defer close(a.done)
+	for item := range a.queue {
+		a.reportDropped(core)
+		if item.flushed != nil {
+			close(item.flushed)
+		} else {
+			core.write(item.record)
+		}
+	}
+	// records dropped in the last burst before shutdown have no following item to report them
+	a.reportDropped(core)
+}
+
+// reportDropped writes WARN record with count of records dropped since the last report
+func (a *asyncWriter) reportDropped(core *loggerCore) {
+	if dropped := atomic.SwapInt64(&a.dropped, 0); dropped > 0 {
+		core.write(Record{
+			Level:    WARN,
+			Message:  fmt.Sprintf("dropped %d log records due to full buffer", dropped),
+			Metadata: Metadata{},
+		})
+	}
+}
+
+// enqueue passes the record to the writing goroutine, returns false if the writer is already closed
+func (a *asyncWriter) enqueue(record Record) bool {
+	a.lock.RLock()
+	defer a.lock.RUnlock()
+	if a.closed {
+		return false
+	}
+
+	item := asyncItem{record: record}
+	switch a.policy {
+	case OverflowDropNewest:
+		select {
+		case a.queue <- item:
+		default:
+			atomic.AddInt64(&a.dropped, 1)
+		}
+	case OverflowDropOldest:
+		for {
+			select {
+			case a.queue <- item:
+				return true
+			default:
+			}
+			select {
+			case old := <-a.queue:
+				if old.flushed != nil {
+					// flush markers must not be dropped, the marker is queued again, so that it is
+					// processed after the records which were written before it
+					a.queue <- old
+				} else {
+					atomic.AddInt64(&a.dropped, 1)
+				}
+			default:
+			}
+		}
+	default:
+		a.queue <- item
+	}
+	return true
+}
+
+func (a *asyncWriter) flush() {
+	a.lock.RLock()
+	if a.closed {
+		a.lock.RUnlock()
+		return
+	}
+	flushed := make(chan struct{})
+	a.queue <- asyncItem{flushed: flushed}
+	a.lock.RUnlock()
+	<-flushed
+}
+
+// shutdown writes all pending records and stops the writing goroutine
+func (a *asyncWriter) shutdown() {
+	a.lock.Lock()
+	a.closed = true
+	close(a.queue)
+	a.lock.Unlock()
+	<-a.done
+}
@@ -0,0 +1,66 @@
+package logging
+
+import (
+	"bytes"
+	"fmt"
+	"path/filepath"
+	"runtime"
+	"strings"
+)
+
+// callerDepth is count of stack frames between newRecord and the caller of Logger's
+// logging method (newRecord, writeRecord and the logging method itself)
+const callerDepth = 3
+
+func shortFunction(name string) string {
+	// strip package path, eg. github.com/infrawatch/apputils/scheduler.(*Scheduler).Start
+	// is shortened to scheduler.(*Scheduler).Start
+	if idx := strings.LastIndex(name, "/"); idx != -1 {
+		return name[idx+1:]
+	}
+	return name
+}
+
+func shortFile(path string) string {
+	return filepath.Join(filepath.Base(filepath.Dir(path)), filepath.Base(path))
+}
+
+// caller returns "file:line" and function name of the caller skip frames above its caller
+func caller(skip int) (string, string) {
+	pc, file, line, ok := runtime.Caller(skip + 1)
+	if !ok {
+		return "", ""
+	}
+	function := ""
+	if fn := runtime.FuncForPC(pc); fn != nil {
+		function = shortFunction(fn.Name())
+	}
+	return fmt.Sprintf("%s:%d", shortFile(file), line), function
+}
+
+// callerOf returns "file:line" and function name of the call with given program counter
+// as returned by runtime.Callers
+func callerOf(pc uintptr) (string, string) {
+	frame, _ := runtime.CallersFrames([]uintptr{pc}).Next()
+	if frame.File == "" {
+		return "", ""
+	}
+	return fmt.Sprintf("%s:%d", shortFile(frame.File), frame.Line), shortFunction(frame.Function)
+}
+
+// stack returns stack trace starting with the caller skip frames above its caller
+func stack(skip int) string {
+	pcs := make([]uintptr, 64)
+	count := runtime.Callers(skip+2, pcs)
+	frames := runtime.CallersFrames(pcs[:count])
+
+	var build bytes.Buffer
+	for {
+		frame, more := frames.Next()
+		fmt.Fprintf(&build, "%s\n\t%s:%d\n", frame.Function, frame.File, frame.Line)
+		if !more {
+			break
+		}
+	}
+	return build.String()
+}
@@ -0,0 +1,129 @@
+package logging
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"sort"
+	"strings"
+)
+
+// RootLogger is the name under which the level of the logger itself is reported and changed
+// by the level control endpoints
+const RootLogger = "root"
+
+func controlLevels(logger *Logger) map[string]string {
+	levels := make(map[string]string)
+	for name, level := range logger.Levels() {
+		levels[name] = level.String()
+	}
+	logger.core.lock.Lock()
+	levels[RootLogger] = logger.Level.String()
+	logger.core.lock.Unlock()
+	return levels
+}
+
+func controlSetLevel(logger *Logger, name string, levelName string) error {
+	level, err := ParseLogLevel(levelName)
+	if err != nil {
+		return err
+	}
+	if name == RootLogger || name == "" {
+		logger.SetLogLevel(level)
+		return nil
+	}
+	return logger.SetLevelOf(name, level)
+}
+
+// NewLevelHandler creates HTTP handler for runtime control of levels of the logger and its
+// named sub-loggers. GET request reports levels of all loggers as JSON object, PUT or POST
+// request with "logger" and "level" parameters changes level of the given logger. The logger
+// itself is addressed by name RootLogger.
+func NewLevelHandler(logger *Logger) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet:
+		case http.MethodPut, http.MethodPost:
+			name := r.FormValue("logger")
+			if err := controlSetLevel(logger, name, r.FormValue("level")); err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+			logger.Info("Changed log level", Metadata{"logger": name, "level": r.FormValue("level")})
+		default:
+			w.Header().Set("Allow", "GET, PUT, POST")
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(controlLevels(logger))
+	})
+}
+
+// LevelControl serves runtime control of log levels on a unix socket
+type LevelControl struct {
+	listener net.Listener
+	logger   *Logger
+}
+
+// ServeLevelControl starts serving runtime control of levels of the logger and its named sub-loggers
+// on a unix socket with given path. The protocol is line based: command "get" returns lines "<logger> <LEVEL>"
+// followed by empty line, command "set <logger> <LEVEL>" changes the level and returns "OK" or "ERROR <reason>".
+func ServeLevelControl(logger *Logger, path string) (*LevelControl, error) {
+	os.Remove(path)
+	listener, err := net.Listen("unix", path)
+	if err != nil {
+		return nil, err
+	}
+	control := &LevelControl{listener: listener, logger: logger}
+	go control.serve()
+	return control, nil
+}
+
+func (c *LevelControl) serve() {
+	for {
+		conn, err := c.listener.Accept()
+		if err != nil {
+			return
+		}
+		go c.handle(conn)
+	}
+}
+
+func (c *LevelControl) handle(conn net.Conn) {
+	defer conn.Close()
+	scanner := bufio.NewScanner(conn)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		switch {
+		case len(fields) == 1 && fields[0] == "get":
+			levels := controlLevels(c.logger)
+			names := make([]string, 0, len(levels))
+			for name := range levels {
+				names = append(names, name)
+			}
+			sort.Strings(names)
+			for _, name := range names {
+				fmt.Fprintf(conn, "%s %s\n", name, levels[name])
+			}
+			fmt.Fprintln(conn)
+		case len(fields) == 3 && fields[0] == "set":
+			if err := controlSetLevel(c.logger, fields[1], fields[2]); err != nil {
+				fmt.Fprintf(conn, "ERROR %s\n", err)
+				continue
+			}
+			c.logger.Info("Changed log level", Metadata{"logger": fields[1], "level": fields[2]})
+			fmt.Fprintln(conn, "OK")
+		default:
+			fmt.Fprintf(conn, "ERROR unknown command: %s\n", scanner.Text())
+		}
+	}
+}
+
+// Close stops serving the level control and removes the socket
+func (c *LevelControl) Close() error {
+	return c.listener.Close()
+}
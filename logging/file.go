@@ -0,0 +1,241 @@
+package logging
+
+import (
+	"compress/gzip"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+	"time"
+)
+
+// Reopener is implemented by sinks which can reopen their target, eg. after the log file
+// was moved by external logrotate
+type Reopener interface {
+	Reopen() error
+}
+
+// RotationOptions configures rotation of log files. Zero values disable the appropriate
+// rotation trigger or limit.
+type RotationOptions struct {
+	// MaxSize is size in bytes after which the log file is rotated
+	MaxSize int64
+	// MaxAge is time after which the log file is rotated
+	MaxAge time.Duration
+	// MaxBackups is count of rotated files to keep, zero keeps all of them
+	MaxBackups int
+	// Compress rotated files with gzip. Compression runs in background, its failure is reported
+	// by the following rotation or by closing the sink and the uncompressed file is kept
+	// as <path>.<timestamp>.rotated.
+	Compress bool
+}
+
+type fileSink struct {
+	path        string
+	permissions os.FileMode
+	rotation    RotationOptions
+	logfile     *os.File
+	size        int64
+	opened      time.Time
+	formatter   Formatter
+	compressing sync.WaitGroup
+	compressErr error
+}
+
+// NewFileSink creates sink appending formatted records to given file
+func NewFileSink(path string, permissions os.FileMode) (Sink, error) {
+	return NewRotatingFileSink(path, permissions, RotationOptions{})
+}
+
+// NewRotatingFileSink creates sink appending formatted records to given file. Rotated files
+// are saved as <path>.1 (the newest), <path>.2, etc. with ".gz" suffix if compressed.
+func NewRotatingFileSink(path string, permissions os.FileMode, rotation RotationOptions) (Sink, error) {
+	sink := fileSink{
+		path:        path,
+		permissions: permissions,
+		rotation:    rotation,
+		formatter:   TextFormatter{},
+	}
+	if err := sink.open(); err != nil {
+		return nil, err
+	}
+	return &sink, nil
+}
+
+func (s *fileSink) open() error {
+	logfile, err := os.OpenFile(s.path, os.O_RDWR|os.O_CREATE|os.O_APPEND, s.permissions)
+	if err != nil {
+		return err
+	}
+	info, err := logfile.Stat()
+	if err != nil {
+		logfile.Close()
+		return err
+	}
+	s.logfile = logfile
+	s.size = info.Size()
+	s.opened = s.started(info)
+	return nil
+}
+
+// started estimates when writing to the opened log file started, so that the age of the file
+// is kept across restarts and reopening. Non-empty file was started at the last rotation, which
+// is the modification time of the newest backup, or at its own modification time if there is none.
+// Compressed backups are created at rotation, uncompressed ones are touched when rotated.
+func (s *fileSink) started(info os.FileInfo) time.Time {
+	if info.Size() == 0 {
+		return time.Now()
+	}
+	if backup, err := os.Stat(s.backupName(1)); err == nil && backup.ModTime().Before(info.ModTime()) {
+		return backup.ModTime()
+	}
+	return info.ModTime()
+}
+
+func (s *fileSink) Write(record Record) error {
+	line, err := s.formatter.Format(record)
+	if err != nil {
+		return err
+	}
+	var rotateErr error
+	if s.shouldRotate(int64(len(line))) {
+		// the log file is reopened even if rotation fails, so the record is not lost
+		rotateErr = s.rotate()
+	}
+	n, err := s.logfile.Write(line)
+	s.size += int64(n)
+	if err == nil && rotateErr != nil {
+		err = fmt.Errorf("failed to rotate log file: %s", rotateErr)
+	}
+	return err
+}
+
+func (s *fileSink) SetFormatter(formatter Formatter) {
+	s.formatter = formatter
+}
+
+// Reopen closes the log file and opens it again on the same path
+func (s *fileSink) Reopen() error {
+	if err := s.logfile.Close(); err != nil {
+		return err
+	}
+	return s.open()
+}
+
+// Close closes the log file and waits for compression of the rotated file
+func (s *fileSink) Close() error {
+	err := s.logfile.Close()
+	if e := s.waitCompression(); err == nil {
+		err = e
+	}
+	return err
+}
+
+func (s *fileSink) shouldRotate(length int64) bool {
+	if s.size == 0 {
+		return false
+	}
+	if s.rotation.MaxSize > 0 && s.size+length > s.rotation.MaxSize {
+		return true
+	}
+	return s.rotation.MaxAge > 0 && time.Since(s.opened) >= s.rotation.MaxAge
+}
+
+func (s *fileSink) backupName(index int) string {
+	name := fmt.Sprintf("%s.%d", s.path, index)
+	if s.rotation.Compress {
+		name += ".gz"
+	}
+	return name
+}
+
+func (s *fileSink) rotate() error {
+	if err := s.logfile.Close(); err != nil {
+		return err
+	}
+	// backups can be shifted only after the previous rotated file is compressed
+	err := s.waitCompression()
+	if e := s.moveBackups(); err == nil {
+		err = e
+	}
+	// reopen the log file even if rotation failed, so that logging can continue
+	if e := s.open(); err == nil {
+		err = e
+	}
+	return err
+}
+
+func (s *fileSink) moveBackups() error {
+	// find the oldest backup, drop backups over the limit and shift the rest by one
+	last := 1
+	for {
+		if _, err := os.Stat(s.backupName(last)); err != nil {
+			break
+		}
+		last++
+	}
+	if s.rotation.MaxBackups > 0 {
+		for ; last > s.rotation.MaxBackups; last-- {
+			os.Remove(s.backupName(last))
+		}
+	}
+	for index := last - 1; index > 0; index-- {
+		if err := os.Rename(s.backupName(index), s.backupName(index+1)); err != nil {
+			return err
+		}
+	}
+
+	if !s.rotation.Compress {
+		if err := os.Rename(s.path, s.backupName(1)); err != nil {
+			return err
+		}
+		// modification time of the newest backup marks start of the current file, see started
+		now := time.Now()
+		return os.Chtimes(s.backupName(1), now, now)
+	}
+	// move the file aside, so that logging can continue while it is compressed, the file is kept
+	// under the unique name if compression fails
+	rotated := fmt.Sprintf("%s.%d.rotated", s.path, time.Now().UnixNano())
+	if err := os.Rename(s.path, rotated); err != nil {
+		return err
+	}
+	s.compressing.Add(1)
+	go func(target string) {
+		defer s.compressing.Done()
+		s.compressErr = compressFile(rotated, target, s.permissions)
+	}(s.backupName(1))
+	return nil
+}
+
+// waitCompression waits for compression of the rotated file and returns its result
+func (s *fileSink) waitCompression() error {
+	s.compressing.Wait()
+	err := s.compressErr
+	s.compressErr = nil
+	return err
+}
+
+func compressFile(source, target string, permissions os.FileMode) error {
+	in, err := os.Open(source)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	out, err := os.OpenFile(target, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, permissions)
+	if err != nil {
+		return err
+	}
+	writer := gzip.NewWriter(out)
+	if _, err = io.Copy(writer, in); err == nil {
+		err = writer.Close()
+	}
+	if e := out.Close(); err == nil {
+		err = e
+	}
+	if err != nil {
+		os.Remove(target)
+		return err
+	}
+	return os.Remove(source)
+}
@@ -0,0 +1,149 @@
+package logging
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Formatter converts log records to lines written by byte oriented sinks
+type Formatter interface {
+	Format(record Record) ([]byte, error)
+}
+
+// FormattedSink is implemented by sinks which write records formatted by Formatter
+type FormattedSink interface {
+	Sink
+	SetFormatter(formatter Formatter)
+}
+
+func sortedKeys(metadata Metadata) []string {
+	keys := make([]string, 0, len(metadata))
+	for key := range metadata {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// TextFormatter formats records to the "[LEVEL] message [key: value, ...]" form
+type TextFormatter struct{}
+
+// Format implements Formatter
+func (f TextFormatter) Format(record Record) ([]byte, error) {
+	//var build strings.Builder
+	// Note: we need to support go-1.9.2 because of CentOS7
+	var build bytes.Buffer
+	if !record.Time.IsZero() {
+		build.WriteString(record.Time.Format("2006-01-02 15:04:05 "))
+	}
+	fmt.Fprintf(&build, "[%s] %s", record.Level, record.Message)
+	if len(record.Metadata) > 0 {
+		fmt.Fprintf(&build, " [%s]", formatMetadata(record.Metadata))
+	}
+	if record.Caller != "" {
+		fmt.Fprintf(&build, " (%s %s)", record.Caller, record.Function)
+	}
+	build.WriteString("\n")
+	if record.Stack != "" {
+		build.WriteString(record.Stack)
+	}
+	return build.Bytes(), nil
+}
+
+// JSONFormatter formats records to JSON objects with keys "time", "level", "message", "caller",
+// "function" and "stack" followed by metadata keys in alphabetical order. Metadata keys colliding
+// with the record keys are prefixed with "metadata.". Keys "caller", "function" and "stack" are present
+// only if the logger has Caller or Stack enabled. Key "time" is always present unless OmitTime is set,
+// records of loggers without Timestamp enabled get the time of formatting.
+type JSONFormatter struct {
+	// OmitTime leaves out key "time" from records without time
+	OmitTime bool
+}
+
+func jsonValue(value interface{}) []byte {
+	if err, ok := value.(error); ok {
+		value = err.Error()
+	}
+	out, err := json.Marshal(value)
+	if err != nil {
+		out, _ = json.Marshal(fmt.Sprintf("%v", value))
+	}
+	return out
+}
+
+// Format implements Formatter
+func (f JSONFormatter) Format(record Record) ([]byte, error) {
+	var build bytes.Buffer
+	build.WriteString("{")
+	recordTime := record.Time
+	if recordTime.IsZero() && !f.OmitTime {
+		recordTime = time.Now()
+	}
+	if !recordTime.IsZero() {
+		fmt.Fprintf(&build, "\"time\":%s,", jsonValue(recordTime.Format(time.RFC3339Nano)))
+	}
+	fmt.Fprintf(&build, "\"level\":%s,\"message\":%s", jsonValue(record.Level.String()), jsonValue(record.Message))
+	if record.Caller != "" {
+		fmt.Fprintf(&build, ",\"caller\":%s,\"function\":%s", jsonValue(record.Caller), jsonValue(record.Function))
+	}
+	if record.Stack != "" {
+		fmt.Fprintf(&build, ",\"stack\":%s", jsonValue(record.Stack))
+	}
+	for _, key := range sortedKeys(record.Metadata) {
+		name := key
+		switch key {
+		case "time", "level", "message", "caller", "function", "stack":
+			name = "metadata." + key
+		}
+		fmt.Fprintf(&build, ",%s:%s", jsonValue(name), jsonValue(record.Metadata[key]))
+	}
+	build.WriteString("}\n")
+	return build.Bytes(), nil
+}
+
+// LogfmtFormatter formats records to logfmt lines: time=... level=... msg=... caller=... function=... key=value ... stack=...
+type LogfmtFormatter struct{}
+
+func logfmtValue(value interface{}) string {
+	str := fmt.Sprintf("%v", value)
+	if str == "" || strings.IndexFunc(str, func(r rune) bool {
+		return r <= ' ' || r == '=' || r == '"' || !strconv.IsPrint(r)
+	}) != -1 {
+		return strconv.Quote(str)
+	}
+	return str
+}
+
+func logfmtKey(key string) string {
+	return strings.Map(func(r rune) rune {
+		if r <= ' ' || r == '=' || r == '"' || !strconv.IsPrint(r) {
+			return '_'
+		}
+		return r
+	}, key)
+}
+
+// Format implements Formatter
+func (f LogfmtFormatter) Format(record Record) ([]byte, error) {
+	var build bytes.Buffer
+	if !record.Time.IsZero() {
+		fmt.Fprintf(&build, "time=%s ", record.Time.Format(time.RFC3339Nano))
+	}
+	fmt.Fprintf(&build, "level=%s msg=%s", record.Level, logfmtValue(record.Message))
+	if record.Caller != "" {
+		fmt.Fprintf(&build, " caller=%s function=%s", logfmtValue(record.Caller), logfmtValue(record.Function))
+	}
+	for _, key := range sortedKeys(record.Metadata) {
+		fmt.Fprintf(&build, " %s=%s", logfmtKey(key), logfmtValue(record.Metadata[key]))
+	}
+	if record.Stack != "" {
+		fmt.Fprintf(&build, " stack=%s", logfmtValue(record.Stack))
+	}
+	build.WriteString("\n")
+	return build.Bytes(), nil
+}
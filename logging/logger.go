@@ -1,10 +1,10 @@
 package logging
 
 import (
-	"bytes"
 	"fmt"
 	"os"
 	"strings"
+	"sync"
 	"time"
 )
 
@@ -16,202 +16,450 @@ const (
 	INFO
 	WARN
 	ERROR
+	CRITICAL
+)
+
+const (
+	// TRACE is the most verbose level, placed below DEBUG so that values of the original levels are kept
+	TRACE LogLevel = DEBUG - 1
+	// FATAL is alias for CRITICAL
+	FATAL = CRITICAL
 )
 
 //Metadata convenience type for setting metadata
 type Metadata map[string]interface{}
 
 func (l LogLevel) String() string {
-	return [...]string{"DEBUG", "INFO", "WARN", "ERROR"}[l]
+	switch l {
+	case TRACE:
+		return "TRACE"
+	case DEBUG:
+		return "DEBUG"
+	case INFO:
+		return "INFO"
+	case WARN:
+		return "WARN"
+	case ERROR:
+		return "ERROR"
+	case CRITICAL:
+		return "CRITICAL"
+	default:
+		return fmt.Sprintf("LogLevel(%d)", int(l))
+	}
 }
 
-type writeFn func(string) error
+// ParseLogLevel converts (case insensitive) level name to LogLevel. Besides the level names
+// it accepts also WARNING, ERR, CRIT and FATAL.
+func ParseLogLevel(name string) (LogLevel, error) {
+	switch strings.ToUpper(strings.TrimSpace(name)) {
+	case "TRACE":
+		return TRACE, nil
+	case "DEBUG":
+		return DEBUG, nil
+	case "INFO":
+		return INFO, nil
+	case "WARN", "WARNING":
+		return WARN, nil
+	case "ERROR", "ERR":
+		return ERROR, nil
+	case "CRITICAL", "CRIT", "FATAL":
+		return CRITICAL, nil
+	default:
+		return INFO, fmt.Errorf("unknown log level: %s", name)
+	}
+}
 
-// Logger implements a simple logger with 4 levels
+// Record holds single log record in the form in which it is passed to sinks
+type Record struct {
+	// Time is zero if the logger does not have Timestamp enabled
+	Time     time.Time
+	Level    LogLevel
+	Message  string
+	Metadata Metadata
+	// Caller holds "file:line" of the logging call if the logger has Caller enabled
+	Caller string
+	// Function holds name of the function doing the logging call if the logger has Caller enabled
+	Function string
+	// Stack holds stack trace of ERROR and CRITICAL records if the logger has Stack enabled
+	Stack string
+}
+
+type sinkEntry struct {
+	sink  Sink
+	level LogLevel
+}
+
+// loggerCore holds state shared by logger and all its children
+type loggerCore struct {
+	// output serializes access to sinks so that they are not used while being written to,
+	// it is always taken before lock
+	output    sync.Mutex
+	lock      sync.Mutex
+	sinks     []sinkEntry
+	formatter Formatter
+	named     map[string]*Logger
+	limiter   *rateLimiter
+	async     *asyncWriter
+}
+
+// Logger implements a simple leveled logger. Logger is safe for concurrent use,
+// contextual metadata should be attached using With or per-call metadata then.
 type Logger struct {
 	Level     LogLevel
 	Timestamp bool
-	metadata  map[string]interface{}
-	logfile   *os.File
-	write     writeFn
+	// Caller enables annotation of records with location of the logging call
+	Caller bool
+	// Stack enables capture of stack trace for ERROR and CRITICAL records
+	Stack    bool
+	name     string
+	fields   Metadata
+	metadata map[string]interface{}
+	core     *loggerCore
 }
 
 // NewLogger logger factory
 func NewLogger(level LogLevel, target string) (*Logger, error) {
-	var logger Logger
-	logger.Level = level
-	logger.Timestamp = false
-	logger.metadata = make(map[string]interface{})
-
+	logger := newLogger(level)
 	switch strings.ToLower(target) {
 	case "console":
-		logger.write = func(message string) error {
-			fmt.Print(message)
-			return nil
-		}
-		break
+		logger.SetSink(NewStdoutSink())
 	default:
-		var err error
-		if logger.logfile == nil {
-			logger.logfile, err = os.OpenFile(target, os.O_RDWR|os.O_CREATE|os.O_APPEND, 0666)
-			if err != nil {
-				return nil, err
-			}
-		}
-		logger.write = func(message string) error {
-			_, err := logger.logfile.WriteString(message)
-			return err
+		sink, err := NewFileSink(target, 0666)
+		if err != nil {
+			return nil, err
 		}
+		logger.SetSink(sink)
 	}
 
-	return &logger, nil
+	return logger, nil
+}
+
+func newLogger(level LogLevel) *Logger {
+	var logger Logger
+	logger.Level = level
+	logger.Timestamp = false
+	logger.metadata = make(map[string]interface{})
+	logger.core = &loggerCore{named: make(map[string]*Logger)}
+	return &logger
 }
 
 // Destroy cleanup resources
 func (l *Logger) Destroy() error {
-	if l.logfile != nil {
-		return l.logfile.Close()
+	l.SetRateLimit(0, 0)
+	l.SetAsync(0, OverflowBlock)
+
+	l.core.output.Lock()
+	defer l.core.output.Unlock()
+	l.core.lock.Lock()
+	sinks := l.core.sinks
+	l.core.sinks = nil
+	l.core.lock.Unlock()
+
+	var err error
+	for _, entry := range sinks {
+		if e := entry.sink.Close(); e != nil && err == nil {
+			err = e
+		}
 	}
+	return err
+}
+
+// With returns child logger which includes given metadata in every record. The child
+// shares targets with its parent and copies its level and timestamp settings.
+func (l *Logger) With(fields Metadata) *Logger {
+	l.core.lock.Lock()
+	defer l.core.lock.Unlock()
+
+	return l.child(fields)
+}
+
+func (l *Logger) child(fields Metadata) *Logger {
+	child := Logger{
+		Level:     l.Level,
+		Timestamp: l.Timestamp,
+		Caller:    l.Caller,
+		Stack:     l.Stack,
+		name:      l.name,
+		fields:    make(Metadata, len(l.fields)+len(fields)),
+		metadata:  make(map[string]interface{}),
+		core:      l.core,
+	}
+	for key, value := range l.fields {
+		child.fields[key] = value
+	}
+	for key, value := range fields {
+		child.fields[key] = value
+	}
+	return &child
+}
+
+// Named returns sub-logger for the given component, eg. "amqp10" or "scheduler". Name of a sub-logger
+// of a named logger is prefixed by the parent's name ("amqp10.receiver"). Records of the sub-logger
+// contain "logger" metadata key with the name. The sub-logger is created with the parent's level
+// and then its level can be changed independently using SetLevelOf. Repeated calls with the same
+// name return the same sub-logger.
+func (l *Logger) Named(name string) *Logger {
+	if l.name != "" {
+		name = l.name + "." + name
+	}
+
+	l.core.lock.Lock()
+	defer l.core.lock.Unlock()
+	if named, ok := l.core.named[name]; ok {
+		return named
+	}
+	named := l.child(Metadata{"logger": name})
+	named.name = name
+	l.core.named[name] = named
+	return named
+}
+
+// SetLevelOf sets level of the sub-logger with the given name. Children created by the sub-logger's
+// With keep the level which the sub-logger had at the time of their creation.
+func (l *Logger) SetLevelOf(name string, level LogLevel) error {
+	l.core.lock.Lock()
+	defer l.core.lock.Unlock()
+	named, ok := l.core.named[name]
+	if !ok {
+		return fmt.Errorf("unknown logger: %s", name)
+	}
+	named.Level = level
 	return nil
 }
 
-// Metadata set metadata to include in message
+// Levels returns current levels of all named sub-loggers
+func (l *Logger) Levels() map[string]LogLevel {
+	l.core.lock.Lock()
+	defer l.core.lock.Unlock()
+	levels := make(map[string]LogLevel, len(l.core.named))
+	for name, named := range l.core.named {
+		levels[name] = named.Level
+	}
+	return levels
+}
+
+// Metadata set metadata to include in the next message. Metadata is shared by all
+// goroutines using the logger, so prefer With or per-call metadata in concurrent code.
 func (l *Logger) Metadata(metadata map[string]interface{}) {
+	l.core.lock.Lock()
+	defer l.core.lock.Unlock()
 	l.metadata = metadata
 }
 
 // SetLogLevel ..
 func (l *Logger) SetLogLevel(level LogLevel) {
+	l.core.lock.Lock()
+	defer l.core.lock.Unlock()
 	l.Level = level
 }
 
-// SetConsole sets logger target to console
+// SetConsole sets the primary logger target to console. Sinks attached by AddSink are kept.
 func (l *Logger) SetConsole() {
-	if l.logfile != nil {
-		err := l.logfile.Close()
-		if err != nil {
-			l.Warn("Failed to close old log file")
-		}
-		l.logfile = nil
-	}
-
-	l.write = func(message string) error {
-		fmt.Print(message)
-		return nil
-	}
+	l.replaceSinks(NewStdoutSink(), false)
 }
 
-// SetFile sets logfile. If logger target was console, switch to file mode
+// SetFile sets logfile as the primary logger target instead of the previous one (eg. console).
+// Sinks attached by AddSink are kept.
 func (l *Logger) SetFile(path string, permissions os.FileMode) error {
-	newLogfile, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE|os.O_APPEND, permissions)
+	sink, err := NewFileSink(path, permissions)
 	if err != nil {
 		l.Warn("Couldn't open new log file, leaving the old one")
 		return err
 	}
+	l.replaceSinks(sink, false)
+	return nil
+}
 
-	if l.logfile != nil {
-		err = l.logfile.Close()
-		if err != nil {
-			l.Warn("Failed to close old log file")
+// SetSink replaces all current logger targets with given sink. Logger takes ownership
+// of the sink and closes it on Destroy or when the target is changed again.
+func (l *Logger) SetSink(sink Sink) {
+	l.replaceSinks(sink, true)
+}
+
+// replaceSinks sets given sink as the primary target and closes replaced sinks. Sinks
+// attached by AddSink are replaced too when all is true.
+func (l *Logger) replaceSinks(sink Sink, all bool) {
+	l.core.output.Lock()
+	l.core.lock.Lock()
+	l.core.applyFormatter(sink)
+	old := l.core.sinks
+	sinks := []sinkEntry{{sink: sink, level: TRACE}}
+	if !all && len(old) > 0 {
+		// the primary target is always the first one
+		sinks = append(sinks, old[1:]...)
+		old = old[:1]
+	}
+	l.core.sinks = sinks
+	l.core.lock.Unlock()
+
+	failed := false
+	for _, entry := range old {
+		if err := entry.sink.Close(); err != nil {
+			failed = true
 		}
-		l.logfile = newLogfile
-		return nil
 	}
+	l.core.output.Unlock()
+	if failed {
+		l.Warn("Failed to close old log sink")
+	}
+}
 
-	//target was console
-	l.logfile = newLogfile
-	l.write = func(message string) error {
-		_, err := l.logfile.WriteString(message)
-		return err
+// AddSink attaches another target to the logger. The sink receives only records with
+// given level or higher, records are filtered by the logger's Level first.
+func (l *Logger) AddSink(sink Sink, level LogLevel) {
+	l.core.lock.Lock()
+	defer l.core.lock.Unlock()
+	l.core.applyFormatter(sink)
+	l.core.sinks = append(l.core.sinks, sinkEntry{sink: sink, level: level})
+}
+
+// SetFormatter sets formatter of all current and future logger sinks which write
+// formatted lines. By default records are formatted by TextFormatter.
+func (l *Logger) SetFormatter(formatter Formatter) {
+	l.core.output.Lock()
+	defer l.core.output.Unlock()
+	l.core.lock.Lock()
+	defer l.core.lock.Unlock()
+	l.core.formatter = formatter
+	for _, entry := range l.core.sinks {
+		l.core.applyFormatter(entry.sink)
 	}
-	return nil
 }
 
-func (l *Logger) formatMetadata() (string, error) {
-	//var build strings.Builder
-	// Note: we need to support go-1.9.2 because of CentOS7
-	var build bytes.Buffer
-	if len(l.metadata) > 0 {
-		joiner := ""
-		for key, item := range l.metadata {
-			_, err := fmt.Fprintf(&build, "%s%s: %v", joiner, key, item)
-			if err != nil {
-				return build.String(), err
-			}
-			if len(joiner) == 0 {
-				joiner = ", "
+// Reopen reopens targets of all logger sinks which support it, eg. log files moved
+// by external logrotate
+func (l *Logger) Reopen() error {
+	l.core.output.Lock()
+	defer l.core.output.Unlock()
+	l.core.lock.Lock()
+	defer l.core.lock.Unlock()
+
+	var err error
+	for _, entry := range l.core.sinks {
+		if reopener, ok := entry.sink.(Reopener); ok {
+			if e := reopener.Reopen(); e != nil && err == nil {
+				err = e
 			}
 		}
 	}
-	// clear metadata for next use
-	l.metadata = make(map[string]interface{})
-	return build.String(), nil
+	return err
 }
 
-func (l *Logger) writeRecord(level LogLevel, message string) error {
-	metadata, err := l.formatMetadata()
-	if err != nil {
-		return err
-	}
-
-	//var build strings.Builder
-	// Note: we need to support go-1.9.2 because of CentOS7
-	var build bytes.Buffer
-	if l.Timestamp {
-		_, err = build.WriteString(time.Now().Format("2006-01-02 15:04:05 "))
+func (c *loggerCore) applyFormatter(sink Sink) {
+	if formatted, ok := sink.(FormattedSink); ok && c.formatter != nil {
+		formatted.SetFormatter(c.formatter)
 	}
+}
 
-	_, err = build.WriteString(fmt.Sprintf("[%s] ", level))
-	if err != nil {
+// writeRecord creates and writes the record. Adapters calling it through skip extra stack frames
+// between the caller and writeRecord, or pass program counter of the caller if they know it.
+func (l *Logger) writeRecord(level LogLevel, message string, fields []Metadata, skip int, pc uintptr) error {
+	l.core.lock.Lock()
+	record, ok := l.newRecord(level, message, fields, skip, pc)
+	async := l.core.async
+	l.core.lock.Unlock()
+	if !ok {
 		return nil
 	}
-	_, err = build.WriteString(message)
-	if err != nil {
+	if async != nil && async.enqueue(record) {
 		return nil
 	}
-	if len(metadata) > 0 {
-		_, err = build.WriteString(fmt.Sprintf(" [%s]", metadata))
-		if err != nil {
-			return nil
+	return l.core.write(record)
+}
+
+// newRecord creates record for the logging call, returns false if the record is filtered out.
+// It has to be called with core lock held.
+func (l *Logger) newRecord(level LogLevel, message string, fields []Metadata, skip int, pc uintptr) (Record, bool) {
+	// metadata set by Metadata belongs to this call even if the record is filtered out
+	pending := l.metadata
+	if len(pending) > 0 {
+		l.metadata = make(map[string]interface{})
+	}
+	if level < l.Level {
+		return Record{}, false
+	}
+	if l.core.limiter != nil && !l.core.limiter.allow(l, level, message) {
+		return Record{}, false
+	}
+
+	record := Record{
+		Level:    level,
+		Message:  message,
+		Metadata: make(Metadata, len(l.fields)+len(pending)),
+	}
+	if l.Timestamp {
+		record.Time = time.Now()
+	}
+	if l.Caller && pc != 0 {
+		record.Caller, record.Function = callerOf(pc)
+	} else if l.Caller {
+		record.Caller, record.Function = caller(callerDepth + skip)
+	}
+	if l.Stack && level >= ERROR {
+		record.Stack = stack(callerDepth + skip)
+	}
+	for key, value := range l.fields {
+		record.Metadata[key] = value
+	}
+	for key, value := range pending {
+		record.Metadata[key] = value
+	}
+	for _, f := range fields {
+		for key, value := range f {
+			record.Metadata[key] = value
 		}
 	}
-	_, err = build.WriteString("\n")
-	if err != nil {
-		return nil
+	return record, true
+}
+
+// write passes records to all sinks, it must not be called with core lock held
+func (c *loggerCore) write(records ...Record) error {
+	c.output.Lock()
+	defer c.output.Unlock()
+	c.lock.Lock()
+	sinks := c.sinks
+	c.lock.Unlock()
+
+	var err error
+	for _, record := range records {
+		for _, entry := range sinks {
+			if record.Level < entry.level {
+				continue
+			}
+			if e := entry.sink.Write(record); e != nil && err == nil {
+				err = e
+			}
+		}
 	}
-    l.metadata = Metadata{} //clear metadata
-	err = l.write(build.String())
 	return err
 }
 
+// Trace level trace
+func (l *Logger) Trace(message string, fields ...Metadata) error {
+	return l.writeRecord(TRACE, message, fields, 0, 0)
+}
+
 // Debug level debug
-func (l *Logger) Debug(message string) error {
-	if l.Level == DEBUG {
-		return l.writeRecord(DEBUG, message)
-	}
-	return nil
+func (l *Logger) Debug(message string, fields ...Metadata) error {
+	return l.writeRecord(DEBUG, message, fields, 0, 0)
 }
 
 // Info level info
-func (l *Logger) Info(message string) error {
-	if l.Level <= INFO {
-		return l.writeRecord(INFO, message)
-	}
-	return nil
+func (l *Logger) Info(message string, fields ...Metadata) error {
+	return l.writeRecord(INFO, message, fields, 0, 0)
 }
 
 // Warn level warn
-func (l *Logger) Warn(message string) error {
-	if l.Level <= WARN {
-		return l.writeRecord(WARN, message)
-	}
-	return nil
+func (l *Logger) Warn(message string, fields ...Metadata) error {
+	return l.writeRecord(WARN, message, fields, 0, 0)
 }
 
 // Error level error
-func (l *Logger) Error(message string) error {
-	if l.Level <= ERROR {
-		return l.writeRecord(ERROR, message)
-	}
-	return nil
+func (l *Logger) Error(message string, fields ...Metadata) error {
+	return l.writeRecord(ERROR, message, fields, 0, 0)
+}
+
+// Critical level critical
+func (l *Logger) Critical(message string, fields ...Metadata) error {
+	return l.writeRecord(CRITICAL, message, fields, 0, 0)
 }
@@ -0,0 +1,124 @@
+package logging
+
+import (
+	"fmt"
+	"time"
+)
+
+type limiterKey struct {
+	level   LogLevel
+	message string
+}
+
+type limiterEntry struct {
+	count      int
+	suppressed int
+	fields     Metadata
+	timestamp  bool
+}
+
+// rateLimiter suppresses identical records (same level and message) over the burst count
+// per interval and keeps count of suppressed records for the periodic summary
+type rateLimiter struct {
+	interval time.Duration
+	burst    int
+	entries  map[limiterKey]*limiterEntry
+	stop     chan struct{}
+	done     chan struct{}
+}
+
+// SetRateLimit enables rate limiting of repeated records. Only burst records with identical
+// level and message are written per interval, the rest is suppressed and a "suppressed N
+// similar messages" record is written at the end of the interval instead. Burst 1 means plain
+// deduplication. Zero interval disables rate limiting.
+func (l *Logger) SetRateLimit(interval time.Duration, burst int) {
+	l.core.lock.Lock()
+	old := l.core.limiter
+	l.core.limiter = nil
+	l.core.lock.Unlock()
+	if old != nil {
+		old.shutdown()
+		l.core.write(old.flush()...)
+	}
+
+	if interval <= 0 {
+		return
+	}
+	if burst < 1 {
+		burst = 1
+	}
+	limiter := &rateLimiter{
+		interval: interval,
+		burst:    burst,
+		entries:  make(map[limiterKey]*limiterEntry),
+		stop:     make(chan struct{}),
+		done:     make(chan struct{}),
+	}
+	l.core.lock.Lock()
+	l.core.limiter = limiter
+	l.core.lock.Unlock()
+	go limiter.run(l.core)
+}
+
+func (r *rateLimiter) run(core *loggerCore) {
+	defer close(r.done)
+	ticker := time.NewTicker(r.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-r.stop:
+			return
+		case <-ticker.C:
+			core.lock.Lock()
+			records := r.flush()
+			core.lock.Unlock()
+			core.write(records...)
+		}
+	}
+}
+
+func (r *rateLimiter) shutdown() {
+	close(r.stop)
+	<-r.done
+}
+
+// allow returns false if the record should be suppressed, has to be called with core lock held
+func (r *rateLimiter) allow(l *Logger, level LogLevel, message string) bool {
+	key := limiterKey{level: level, message: message}
+	entry, ok := r.entries[key]
+	if !ok {
+		entry = &limiterEntry{fields: l.fields, timestamp: l.Timestamp}
+		r.entries[key] = entry
+	}
+	entry.count++
+	if entry.count > r.burst {
+		entry.suppressed++
+		return false
+	}
+	return true
+}
+
+// flush returns summary records and starts a new interval, has to be called with core lock held
+// unless the limiter is already detached from the logger
+func (r *rateLimiter) flush() []Record {
+	var records []Record
+	for key, entry := range r.entries {
+		if entry.suppressed > 0 {
+			record := Record{
+				Level:    key.level,
+				Message:  fmt.Sprintf("suppressed %d similar messages", entry.suppressed),
+				Metadata: make(Metadata, len(entry.fields)+1),
+			}
+			if entry.timestamp {
+				record.Time = time.Now()
+			}
+			for name, value := range entry.fields {
+				record.Metadata[name] = value
+			}
+			record.Metadata["suppressed_message"] = key.message
+			records = append(records, record)
+		}
+	}
+	r.entries = make(map[limiterKey]*limiterEntry)
+	return records
+}
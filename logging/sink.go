@@ -0,0 +1,156 @@
+package logging
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"strings"
+)
+
+const journaldSocket = "/run/systemd/journal/socket"
+
+// Sink receives complete log records and writes them to the logger target
+type Sink interface {
+	Write(record Record) error
+	Close() error
+}
+
+func formatMetadata(metadata Metadata) string {
+	//var build strings.Builder
+	// Note: we need to support go-1.9.2 because of CentOS7
+	var build bytes.Buffer
+	joiner := ""
+	for _, key := range sortedKeys(metadata) {
+		fmt.Fprintf(&build, "%s%s: %v", joiner, key, metadata[key])
+		joiner = ", "
+	}
+	return build.String()
+}
+
+type writerSink struct {
+	writer    io.Writer
+	formatter Formatter
+}
+
+// NewWriterSink creates sink writing formatted records to given writer. The writer
+// is not closed by the sink.
+func NewWriterSink(writer io.Writer) Sink {
+	return &writerSink{writer: writer, formatter: TextFormatter{}}
+}
+
+// NewStdoutSink creates sink writing formatted records to standard output
+func NewStdoutSink() Sink {
+	return NewWriterSink(os.Stdout)
+}
+
+// NewStderrSink creates sink writing formatted records to standard error output
+func NewStderrSink() Sink {
+	return NewWriterSink(os.Stderr)
+}
+
+func (s *writerSink) Write(record Record) error {
+	line, err := s.formatter.Format(record)
+	if err != nil {
+		return err
+	}
+	_, err = s.writer.Write(line)
+	return err
+}
+
+func (s *writerSink) SetFormatter(formatter Formatter) {
+	s.formatter = formatter
+}
+
+func (s *writerSink) Close() error {
+	return nil
+}
+
+type journaldSink struct {
+	conn       *net.UnixConn
+	identifier string
+}
+
+// NewJournaldSink creates sink sending records to systemd-journald using its native
+// protocol. Metadata keys are sent as journal fields with name converted to upper case.
+func NewJournaldSink(identifier string) (Sink, error) {
+	conn, err := net.DialUnix("unixgram", nil, &net.UnixAddr{Name: journaldSocket, Net: "unixgram"})
+	if err != nil {
+		return nil, err
+	}
+	return &journaldSink{conn: conn, identifier: identifier}, nil
+}
+
+func journaldPriority(level LogLevel) int {
+	switch {
+	case level <= DEBUG:
+		return 7
+	case level == INFO:
+		return 6
+	case level == WARN:
+		return 4
+	case level == ERROR:
+		return 3
+	default:
+		return 2
+	}
+}
+
+func journaldFieldName(key string) string {
+	name := strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z':
+			return r - 'a' + 'A'
+		case r >= 'A' && r <= 'Z', r >= '0' && r <= '9':
+			return r
+		default:
+			return '_'
+		}
+	}, key)
+	// fields starting with underscore are reserved for journald itself
+	return strings.TrimLeft(name, "_")
+}
+
+func writeJournaldField(buf *bytes.Buffer, name, value string) {
+	if strings.ContainsRune(value, '\n') {
+		buf.WriteString(name)
+		buf.WriteByte('\n')
+		binary.Write(buf, binary.LittleEndian, uint64(len(value)))
+		buf.WriteString(value)
+		buf.WriteByte('\n')
+		return
+	}
+	fmt.Fprintf(buf, "%s=%s\n", name, value)
+}
+
+func (s *journaldSink) Write(record Record) error {
+	var buf bytes.Buffer
+	writeJournaldField(&buf, "MESSAGE", record.Message)
+	writeJournaldField(&buf, "PRIORITY", fmt.Sprintf("%d", journaldPriority(record.Level)))
+	if s.identifier != "" {
+		writeJournaldField(&buf, "SYSLOG_IDENTIFIER", s.identifier)
+	}
+	if record.Caller != "" {
+		if idx := strings.LastIndex(record.Caller, ":"); idx != -1 {
+			writeJournaldField(&buf, "CODE_FILE", record.Caller[:idx])
+			writeJournaldField(&buf, "CODE_LINE", record.Caller[idx+1:])
+		}
+		writeJournaldField(&buf, "CODE_FUNC", record.Function)
+	}
+	if record.Stack != "" {
+		writeJournaldField(&buf, "STACK", record.Stack)
+	}
+	for key, value := range record.Metadata {
+		if name := journaldFieldName(key); name != "" {
+			writeJournaldField(&buf, name, fmt.Sprintf("%v", value))
+		}
+	}
+	_, err := s.conn.Write(buf.Bytes())
+	return err
+}
+
+func (s *journaldSink) Close() error {
+	return s.conn.Close()
+}
@@ -0,0 +1,155 @@
+//go:build go1.21
+// +build go1.21
+
+package logging
+
+import (
+	"context"
+	"log/slog"
+	"sort"
+)
+
+// FromSlogLevel converts slog level to LogLevel. Levels between the slog ones are rounded down,
+// levels below slog.LevelDebug map to TRACE and levels above slog.LevelError to CRITICAL.
+func FromSlogLevel(level slog.Level) LogLevel {
+	switch {
+	case level < slog.LevelDebug:
+		return TRACE
+	case level < slog.LevelInfo:
+		return DEBUG
+	case level < slog.LevelWarn:
+		return INFO
+	case level < slog.LevelError:
+		return WARN
+	case level < slog.LevelError+4:
+		return ERROR
+	default:
+		return CRITICAL
+	}
+}
+
+// ToSlogLevel converts LogLevel to slog level. TRACE and CRITICAL, which don't have slog
+// counterparts, map to slog.LevelDebug-4 and slog.LevelError+4.
+func ToSlogLevel(level LogLevel) slog.Level {
+	switch {
+	case level <= TRACE:
+		return slog.LevelDebug - 4
+	case level == DEBUG:
+		return slog.LevelDebug
+	case level == INFO:
+		return slog.LevelInfo
+	case level == WARN:
+		return slog.LevelWarn
+	case level == ERROR:
+		return slog.LevelError
+	default:
+		return slog.LevelError + 4
+	}
+}
+
+type slogHandler struct {
+	logger *Logger
+	group  string
+}
+
+// NewSlogHandler creates slog.Handler which passes records to the logger, so that output of libraries
+// using log/slog ends up in the same sinks and format as the rest. Attributes become record metadata,
+// keys of attributes in groups are prefixed by group names joined with dots.
+func NewSlogHandler(logger *Logger) slog.Handler {
+	return &slogHandler{logger: logger}
+}
+
+func (h *slogHandler) Enabled(_ context.Context, level slog.Level) bool {
+	h.logger.core.lock.Lock()
+	defer h.logger.core.lock.Unlock()
+	return FromSlogLevel(level) >= h.logger.Level
+}
+
+func (h *slogHandler) Handle(_ context.Context, record slog.Record) error {
+	fields := make(Metadata, record.NumAttrs())
+	record.Attrs(func(attr slog.Attr) bool {
+		addSlogAttr(fields, h.group, attr)
+		return true
+	})
+	return h.logger.writeRecord(FromSlogLevel(record.Level), record.Message, []Metadata{fields}, 0, record.PC)
+}
+
+func (h *slogHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	fields := make(Metadata, len(attrs))
+	for _, attr := range attrs {
+		addSlogAttr(fields, h.group, attr)
+	}
+	return &slogHandler{logger: h.logger.With(fields), group: h.group}
+}
+
+func (h *slogHandler) WithGroup(name string) slog.Handler {
+	if name == "" {
+		return h
+	}
+	return &slogHandler{logger: h.logger, group: h.group + name + "."}
+}
+
+func addSlogAttr(fields Metadata, prefix string, attr slog.Attr) {
+	value := attr.Value.Resolve()
+	if value.Kind() == slog.KindGroup {
+		group := prefix
+		if attr.Key != "" {
+			group = prefix + attr.Key + "."
+		}
+		for _, member := range value.Group() {
+			addSlogAttr(fields, group, member)
+		}
+		return
+	}
+	if attr.Key == "" {
+		return
+	}
+	fields[prefix+attr.Key] = value.Any()
+}
+
+type slogSink struct {
+	handler slog.Handler
+}
+
+// NewSlogSink creates sink passing records to the given slog.Handler. Metadata is passed as attributes,
+// caller annotation and stack trace as "caller", "function" and "stack" attributes.
+func NewSlogSink(handler slog.Handler) Sink {
+	return &slogSink{handler: handler}
+}
+
+// NewSlogLogger creates logger which writes all records to the given slog.Handler
+func NewSlogLogger(handler slog.Handler, level LogLevel) *Logger {
+	logger := newLogger(level)
+	logger.SetSink(NewSlogSink(handler))
+	return logger
+}
+
+func (s *slogSink) Write(record Record) error {
+	ctx := context.Background()
+	level := ToSlogLevel(record.Level)
+	if !s.handler.Enabled(ctx, level) {
+		return nil
+	}
+
+	// zero time makes handlers omit it, which matches disabled Logger.Timestamp
+	out := slog.NewRecord(record.Time, level, record.Message, 0)
+	keys := make([]string, 0, len(record.Metadata))
+	for key := range record.Metadata {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		out.AddAttrs(slog.Any(key, record.Metadata[key]))
+	}
+	if record.Caller != "" {
+		out.AddAttrs(slog.String("caller", record.Caller), slog.String("function", record.Function))
+	}
+	if record.Stack != "" {
+		out.AddAttrs(slog.String("stack", record.Stack))
+	}
+	return s.handler.Handle(ctx, out)
+}
+
+func (s *slogSink) Close() error {
+	return nil
+}
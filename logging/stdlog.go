@@ -0,0 +1,51 @@
+package logging
+
+import (
+	"io"
+	"log"
+	"strings"
+)
+
+// stdLogDepth is count of stack frames the log package puts between its caller and Write
+// of the output writer
+const stdLogDepth = 2
+
+type logWriter struct {
+	logger *Logger
+	level  LogLevel
+}
+
+// Writer returns io.Writer which writes every chunk of data as a record with given level.
+// Trailing newline is stripped from the message.
+func (l *Logger) Writer(level LogLevel) io.Writer {
+	return &logWriter{logger: l, level: level}
+}
+
+func (w *logWriter) Write(data []byte) (int, error) {
+	message := strings.TrimSuffix(string(data), "\n")
+	if err := w.logger.writeRecord(w.level, message, nil, stdLogDepth, 0); err != nil {
+		return 0, err
+	}
+	return len(data), nil
+}
+
+// NewStdLogger creates standard library logger which writes to the logger with given level,
+// for use with libraries which accept *log.Logger
+func NewStdLogger(logger *Logger, level LogLevel) *log.Logger {
+	return log.New(logger.Writer(level), "", 0)
+}
+
+// RedirectStdLog redirects output of the standard log package to the logger with given level.
+// Prefix and flags of the standard logger are cleared, because the logger adds its own timestamp
+// and caller annotation. Returned function restores the original output, prefix and flags.
+func RedirectStdLog(logger *Logger, level LogLevel) func() {
+	output, prefix, flags := log.Writer(), log.Prefix(), log.Flags()
+	log.SetOutput(logger.Writer(level))
+	log.SetPrefix("")
+	log.SetFlags(0)
+	return func() {
+		log.SetOutput(output)
+		log.SetPrefix(prefix)
+		log.SetFlags(flags)
+	}
+}
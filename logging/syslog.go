@@ -0,0 +1,45 @@
+//go:build !windows && !plan9
+// +build !windows,!plan9
+
+package logging
+
+import (
+	"log/syslog"
+	"strings"
+)
+
+type syslogSink struct {
+	writer *syslog.Writer
+}
+
+// NewSyslogSink creates sink sending records to local syslog daemon over its unix socket
+func NewSyslogSink(tag string) (Sink, error) {
+	writer, err := syslog.New(syslog.LOG_INFO|syslog.LOG_USER, tag)
+	if err != nil {
+		return nil, err
+	}
+	return &syslogSink{writer: writer}, nil
+}
+
+func (s *syslogSink) Write(record Record) error {
+	message := record.Message
+	if len(record.Metadata) > 0 {
+		message = strings.Join([]string{message, " [", formatMetadata(record.Metadata), "]"}, "")
+	}
+	switch {
+	case record.Level <= DEBUG:
+		return s.writer.Debug(message)
+	case record.Level == INFO:
+		return s.writer.Info(message)
+	case record.Level == WARN:
+		return s.writer.Warning(message)
+	case record.Level == ERROR:
+		return s.writer.Err(message)
+	default:
+		return s.writer.Crit(message)
+	}
+}
+
+func (s *syslogSink) Close() error {
+	return s.writer.Close()
+}
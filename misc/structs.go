@@ -65,3 +65,29 @@ func MergeMaps(ms ...map[string]interface{}) map[string]interface{} {
 	}
 	return res
 }
+
+// DeepMergeMaps merges given maps into a new one. Unlike MergeMaps nested maps are merged
+// recursively, so later maps override only the keys they contain. Other values of later maps
+// replace values of former ones.
+func DeepMergeMaps(ms ...map[string]interface{}) map[string]interface{} {
+	res := make(map[string]interface{})
+	for _, m := range ms {
+		for k, v := range m {
+			switch value := v.(type) {
+			case map[string]interface{}:
+				if current, ok := res[k].(map[string]interface{}); ok {
+					res[k] = DeepMergeMaps(current, value)
+				} else {
+					res[k] = DeepMergeMaps(value)
+				}
+			case []interface{}:
+				vCopy := make([]interface{}, len(value))
+				copy(vCopy, value)
+				res[k] = vCopy
+			default:
+				res[k] = value
+			}
+		}
+	}
+	return res
+}
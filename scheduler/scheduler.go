@@ -148,16 +148,14 @@ func (sched *Scheduler) executeTask(taskName string, outchan chan Result) {
 	defer sched.wg.Done()
 
 	if _, ok := sched.tasks[taskName]; !ok {
-		sched.log.Metadata(logging.Metadata{"task": taskName})
-		sched.log.Warn("requested execution of canceled task")
+		sched.log.Warn("requested execution of canceled task", logging.Metadata{"task": taskName})
 		return
 	}
 
 	//TODO: timeout
 	result, err := (sched.tasks[taskName]).execute(sched.ctx, sched.log)
 	if err != nil {
-		sched.log.Metadata(logging.Metadata{"task": taskName, "error": err})
-		sched.log.Warn("task execution failed")
+		sched.log.Warn("task execution failed", logging.Metadata{"task": taskName, "error": err})
 	}
 	outchan <- Result{
 		Task:   taskName,
@@ -188,27 +186,26 @@ func (sched *Scheduler) selectTask(outchan chan Result) {
 
 		if ok {
 			// execute task
-			sched.log.Metadata(logging.Metadata{"task": name, "timestamp": ts})
+			taskLog := sched.log.With(logging.Metadata{"task": name, "timestamp": ts})
 			task := sched.tasks[name]
 			switch sched.tasks[name].state {
 			case taskScheduled:
-				sched.log.Debug("executing")
+				taskLog.Debug("executing")
 				sched.wg.Add(1)
 				go sched.executeTask(name, outchan)
 			case taskCancelled:
-				sched.log.Debug("cancelling task")
+				taskLog.Debug("cancelling task")
 				sched.cancelJob(index)
 				task.ticker.Stop()
 			case taskRemoved:
-				sched.log.Debug("deleting task")
+				taskLog.Debug("deleting task")
 				sched.cancelJob(index)
 				task.ticker.Stop()
 				delete(sched.tasks, name)
 			}
 		} else {
 			// task timer channel is closed, so task has been canceled
-			sched.log.Metadata(logging.Metadata{"task": name})
-			sched.log.Debug("deleting task's job due to timer channel close")
+			sched.log.Debug("deleting task's job due to timer channel close", logging.Metadata{"task": name})
 			sched.cancelJob(index)
 		}
 	}
@@ -236,8 +233,7 @@ func (sched *Scheduler) Start(bufferSize int, block bool) chan Result {
 			},
 		})
 
-		sched.log.Metadata(logging.Metadata{"task": name, "timer": sched.tasks[name].interval.String()})
-		sched.log.Debug("started timer for task")
+		sched.log.Debug("started timer for task", logging.Metadata{"task": name, "timer": sched.tasks[name].interval.String()})
 	}
 
 	sched.wg = new(sync.WaitGroup)
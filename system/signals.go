@@ -15,12 +15,32 @@ func SpawnSignalHandler(finish chan bool, logger *logging.Logger, watchedSignals
 	go func() {
 	signalLoop:
 		for sig := range interruptChannel {
-			logger.Metadata(map[string]interface{}{
+			logger.Error("Stopping execution on caught signal", logging.Metadata{
 				"signal": sig,
 			})
-			logger.Error("Stopping execution on caught signal")
 			close(finish)
 			break signalLoop
 		}
 	}()
 }
+
+//SpawnReopenHandler spawns goroutine which reopens log files of the given logger whenever
+// any of given signal(s) (usually SIGHUP sent by logrotate) is received
+func SpawnReopenHandler(logger *logging.Logger, watchedSignals ...os.Signal) {
+	reopenChannel := make(chan os.Signal, 1)
+	signal.Notify(reopenChannel, watchedSignals...)
+	go func() {
+		for sig := range reopenChannel {
+			if err := logger.Reopen(); err != nil {
+				logger.Error("Failed to reopen log files", logging.Metadata{
+					"signal": sig,
+					"error":  err,
+				})
+			} else {
+				logger.Debug("Reopened log files on caught signal", logging.Metadata{
+					"signal": sig,
+				})
+			}
+		}
+	}()
+}
@@ -0,0 +1,99 @@
+package tests
+
+import (
+	"io/ioutil"
+	"os"
+	"path"
+	"testing"
+
+	"github.com/infrawatch/apputils/config"
+	"github.com/infrawatch/apputils/logging"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestConfigAddressing(t *testing.T) {
+	tmpdir, err := ioutil.TempDir(".", "config_test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmpdir)
+
+	log, err := logging.NewLogger(logging.DEBUG, path.Join(tmpdir, "test.log"))
+	if err != nil {
+		t.Fatalf("Failed to create logger: %s", err)
+	}
+	defer log.Destroy()
+
+	iniPath := path.Join(tmpdir, "test.conf")
+	if err := ioutil.WriteFile(iniPath, []byte(IniConfigContent), 0600); err != nil {
+		t.Fatal(err)
+	}
+	iniConf := config.NewINIConfig(map[string][]config.Parameter{
+		"amqp1": []config.Parameter{
+			config.Parameter{Name: "port", Tag: "", Default: 5666, Validators: []config.Validator{config.IntValidatorFactory()}},
+			config.Parameter{Name: "host", Tag: "", Default: "localhost", Validators: []config.Validator{}},
+		},
+	}, log)
+	if err := iniConf.Parse(iniPath); err != nil {
+		t.Fatal(err)
+	}
+
+	yamlConf := config.NewYAMLConfig(JSONConfigMetadata, log)
+	yamlConf.AddStructured("Amqp1", "Connections", `yaml:"connections"`, OuterYAMLTestObject{})
+	if err := yamlConf.ParseBytes([]byte(YAMLConfigContent)); err != nil {
+		t.Fatal(err)
+	}
+
+	t.Run("Test address parsing", func(t *testing.T) {
+		addr, err := config.ParseAddress("Amqp1.Connections.Test")
+		if assert.NoError(t, err) {
+			assert.Equal(t, config.Address{Section: "Amqp1", Option: "Connections", Fields: []string{"Test"}}, addr)
+			assert.Equal(t, "Amqp1.Connections.Test", addr.String())
+		}
+		addr, err = config.ParseAddress("amqp1/port")
+		if assert.NoError(t, err) {
+			assert.Equal(t, "amqp1.port", addr.String())
+		}
+		_, err = config.ParseAddress("amqp1")
+		assert.Error(t, err)
+	})
+
+	t.Run("Test lookup independent of config format", func(t *testing.T) {
+		opt, err := config.Lookup(iniConf, "amqp1.port")
+		if assert.NoError(t, err) {
+			assert.Equal(t, int64(666), opt.GetInt())
+		}
+		opt, err = config.Lookup(yamlConf, "Amqp1.Connections.Test")
+		if assert.NoError(t, err) {
+			assert.Equal(t, "woobalooba", opt.GetString())
+		}
+		_, err = config.Lookup(iniConf, "amqp1.port.sub")
+		assert.Error(t, err)
+	})
+
+	t.Run("Test option mapping", func(t *testing.T) {
+		mapping := config.Mapping{
+			"port": {INI: "amqp1.port", Structured: "Default.Port"},
+			"test": {Structured: "Amqp1.Connections.Test"},
+			"host": {INI: "amqp1.host"},
+		}
+		opt, err := mapping.Get(iniConf, "port")
+		if assert.NoError(t, err) {
+			assert.Equal(t, int64(666), opt.GetInt())
+		}
+		opt, err = mapping.Get(yamlConf, "port")
+		if assert.NoError(t, err) {
+			assert.Equal(t, int64(1234), opt.GetInt())
+		}
+		opt, err = mapping.Get(yamlConf, "test")
+		if assert.NoError(t, err) {
+			assert.Equal(t, "woobalooba", opt.GetString())
+		}
+		opt, err = mapping.Get(iniConf, "host")
+		if assert.NoError(t, err) {
+			assert.Equal(t, "localhost", opt.GetString())
+		}
+		_, err = mapping.Get(iniConf, "unknown")
+		assert.Error(t, err)
+	})
+}
@@ -0,0 +1,156 @@
+package tests
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/infrawatch/apputils/config"
+	"github.com/infrawatch/apputils/logging"
+	"github.com/stretchr/testify/assert"
+)
+
+type DecodeAMQP1 struct {
+	Host     string        `config:"host" default:"localhost"`
+	Port     int           `config:"port" default:"5666" validate:"int"`
+	Channels []string      `config:"channels" default:"metrics,events"`
+	Timeout  time.Duration `config:"timeout" default:"5s"`
+	Level    logging.LogLevel
+	Ignored  string `config:"-"`
+}
+
+type DecodeDefault struct {
+	LogFile   string `config:"log_file"`
+	AllowExec bool   `config:"allow_exec" default:"true"`
+	LogLevel  string `config:"log_level" validate:"upper,options=DEBUG|INFO|WARNING"`
+}
+
+type DecodeINI struct {
+	Default DecodeDefault `config:"default"`
+	Amqp1   DecodeAMQP1   `config:"amqp1"`
+}
+
+type DecodeConnection struct {
+	Test    string `config:"test"`
+	Sources []struct {
+		Type string `json:"type"`
+		URL  string `json:"url"`
+	} `config:"data_sources"`
+	Retries int `config:"retries" default:"3"`
+}
+
+type DecodeJSONAmqp1 struct {
+	Float       float64          `config:"float"`
+	Connections DecodeConnection `config:"connections"`
+}
+
+func TestConfigDecode(t *testing.T) {
+	tmpdir, err := ioutil.TempDir(".", "config_test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmpdir)
+	file := path.Join(tmpdir, "test.conf")
+	if err := ioutil.WriteFile(file, []byte(IniConfigContent), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	log, err := logging.NewLogger(logging.DEBUG, path.Join(tmpdir, "test.log"))
+	if err != nil {
+		t.Fatalf("Failed to create logger: %s", err)
+	}
+	defer log.Destroy()
+
+	config.RegisterValidator("upper", func(arg string) (config.Validator, error) {
+		return func(input interface{}) (interface{}, error) {
+			return strings.ToUpper(fmt.Sprintf("%v", input)), nil
+		}, nil
+	})
+
+	t.Run("Test decoding of INI section", func(t *testing.T) {
+		conf := config.NewINIConfig(map[string][]config.Parameter{}, log)
+		if err := conf.Parse(file); err != nil {
+			t.Fatal(err)
+		}
+		var amqp1 DecodeAMQP1
+		amqp1.Ignored = "untouched"
+		if err := conf.Decode("amqp1", &amqp1); err != nil {
+			t.Fatal(err)
+		}
+		assert.Equal(t, DecodeAMQP1{
+			Host:     "localhost",
+			Port:     666,
+			Channels: []string{"metrics", "events"},
+			Timeout:  5 * time.Second,
+			Ignored:  "untouched",
+		}, amqp1)
+	})
+
+	t.Run("Test unmarshalling of whole INI file", func(t *testing.T) {
+		os.Setenv("DECODE_AMQP1_TIMEOUT", "1m")
+		defer os.Unsetenv("DECODE_AMQP1_TIMEOUT")
+		os.Setenv("DECODE_DEFAULT_LOG_LEVEL", "warning")
+		defer os.Unsetenv("DECODE_DEFAULT_LOG_LEVEL")
+		conf := config.NewINIConfig(map[string][]config.Parameter{}, log)
+		conf.SetEnvPrefix("decode")
+		if err := conf.Parse(file); err != nil {
+			t.Fatal(err)
+		}
+		var whole DecodeINI
+		if err := conf.Unmarshal(&whole); err != nil {
+			t.Fatal(err)
+		}
+		assert.Equal(t, DecodeDefault{LogFile: "/var/tmp/test.log", AllowExec: false, LogLevel: "WARNING"}, whole.Default)
+		assert.Equal(t, time.Minute, whole.Amqp1.Timeout)
+		assert.Equal(t, 666, whole.Amqp1.Port)
+	})
+
+	t.Run("Test decoding of nested JSON values", func(t *testing.T) {
+		conf := config.NewJSONConfig(JSONConfigMetadata, log)
+		if err := conf.ParseBytes([]byte(JSONConfigContent)); err != nil {
+			t.Fatal(err)
+		}
+		var amqp1 DecodeJSONAmqp1
+		if err := conf.Decode("Amqp1", &amqp1); err != nil {
+			t.Fatal(err)
+		}
+		assert.Equal(t, 5.5, amqp1.Float)
+		assert.Equal(t, "woobalooba", amqp1.Connections.Test)
+		assert.Equal(t, 3, amqp1.Connections.Retries)
+		if assert.Equal(t, 2, len(amqp1.Connections.Sources)) {
+			assert.Equal(t, "foobar", amqp1.Connections.Sources[1].URL)
+		}
+	})
+
+	t.Run("Test decoding errors", func(t *testing.T) {
+		conf := config.NewINIConfig(map[string][]config.Parameter{}, log)
+		if err := conf.Parse(file); err != nil {
+			t.Fatal(err)
+		}
+		var invalid struct {
+			Int int `config:"IntValidator" validate:"int"`
+		}
+		err := conf.Decode("invalid", &invalid)
+		if assert.Error(t, err) {
+			assert.Equal(t, "failed validation for parameter invalid.IntValidator: value (whoops) is not int", err.Error())
+		}
+
+		var conversion struct {
+			Bool bool `config:"BoolValidator"`
+		}
+		err = conf.Decode("invalid", &conversion)
+		if assert.Error(t, err) {
+			assert.Equal(t, "failed to decode parameter invalid.BoolValidator: value (no-way) is not bool", err.Error())
+		}
+
+		var unknown struct {
+			Value string `config:"OptionsValidator" validate:"unknown"`
+		}
+		assert.Error(t, conf.Decode("invalid", &unknown))
+		assert.Error(t, conf.Decode("invalid", unknown))
+	})
+}
@@ -0,0 +1,218 @@
+package tests
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path"
+	"testing"
+
+	"github.com/infrawatch/apputils/config"
+	"github.com/infrawatch/apputils/logging"
+	"github.com/stretchr/testify/assert"
+)
+
+var DumpConfigContent = `
+[default]
+log_level=warning
+
+[amqp1]
+host=broker
+password=s3cr3t
+channels=1,2,3
+`
+
+type SecretCredentials struct {
+	User     string `json:"user"`
+	Password string `json:"password" secret:"true"`
+	Token    []byte `json:"token" secret:"true"`
+}
+
+type SecretConnection struct {
+	Address     string              `json:"address"`
+	Credentials *SecretCredentials  `json:"credentials"`
+	Backups     []SecretCredentials `json:"backups"`
+}
+
+func TestConfigDump(t *testing.T) {
+	tmpdir, err := ioutil.TempDir(".", "config_test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmpdir)
+	file := path.Join(tmpdir, "test.conf")
+	if err := ioutil.WriteFile(file, []byte(DumpConfigContent), 0600); err != nil {
+		t.Fatal(err)
+	}
+	logger, err := logging.NewLogger(logging.DEBUG, path.Join(tmpdir, "test.log"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer logger.Destroy()
+
+	metadata := map[string][]config.Parameter{
+		"default": []config.Parameter{
+			config.Parameter{Name: "log_level", Default: "INFO", Validators: []config.Validator{config.LogLevelValidatorFactory()}},
+		},
+		"amqp1": []config.Parameter{
+			config.Parameter{Name: "host", Default: "localhost", Validators: []config.Validator{}},
+			config.Parameter{Name: "port", Default: 5666, Validators: []config.Validator{config.IntValidatorFactory()}},
+			config.Parameter{Name: "password", Default: "guest", Secret: true, Validators: []config.Validator{}},
+			config.Parameter{Name: "channels", Default: "", Validators: []config.Validator{config.MultiIntValidatorFactory(",")}},
+		},
+	}
+	os.Setenv("DUMP_AMQP1_PORT", "5672")
+	defer os.Unsetenv("DUMP_AMQP1_PORT")
+	conf := config.NewINIConfig(metadata, logger)
+	conf.SetEnvPrefix("dump")
+	if err := conf.Parse(file); err != nil {
+		t.Fatal(err)
+	}
+
+	t.Run("Test dump of effective config", func(t *testing.T) {
+		assert.Equal(t, "env", conf.Sections["amqp1"].Options["port"].Source())
+		assert.Equal(t, "file", conf.Sections["amqp1"].Options["host"].Source())
+		assert.True(t, conf.Sections["amqp1"].Options["password"].Secret())
+
+		var out bytes.Buffer
+		assert.NoError(t, conf.Dump(&out, config.DumpOptions{Format: "ini", Sources: true}))
+		assert.Equal(t, `[amqp1]
+# source: file
+host = broker
+# source: env
+port = 5672
+# source: file
+password = <redacted>
+# source: file
+channels = 1,2,3
+
+[default]
+# source: file
+log_level = WARN
+`, out.String())
+
+		out.Reset()
+		assert.NoError(t, conf.Dump(&out, config.DumpOptions{Format: "json", ShowSecrets: true}))
+		assert.JSONEq(t, `{
+			"amqp1": {"host": "broker", "port": 5672, "password": "s3cr3t", "channels": [1, 2, 3]},
+			"default": {"log_level": "WARN"}
+		}`, out.String())
+
+		out.Reset()
+		assert.NoError(t, conf.Dump(&out, config.DumpOptions{Format: "json", Sources: true}))
+		assert.Contains(t, out.String(), `"port": {
+      "source": "env",
+      "value": 5672
+    }`)
+
+		out.Reset()
+		assert.NoError(t, conf.Dump(&out, config.DumpOptions{Format: "yaml", Sources: true}))
+		assert.Equal(t, `amqp1:
+  # source: file
+  host: broker
+  # source: env
+  port: 5672
+  # source: file
+  password: <redacted>
+  # source: file
+  channels:
+    - 1
+    - 2
+    - 3
+default:
+  # source: file
+  log_level: WARN
+`, out.String())
+
+		assert.EqualError(t, conf.Dump(&out, config.DumpOptions{Format: "xml"}), "unsupported dump format 'xml'")
+	})
+
+	t.Run("Test dump of structured config", func(t *testing.T) {
+		jsonConf := config.NewJSONConfig(JSONConfigMetadata, logger)
+		var connections OuterTestObject
+		jsonConf.AddStructured("Amqp1", "Connections", `json:"connections" secret:"true"`, connections)
+		if err := jsonConf.ParseBytes([]byte(`{"Amqp1": {"float": 1.5, "connections": {"test": "a"}}}`)); err != nil {
+			t.Fatal(err)
+		}
+		var out bytes.Buffer
+		assert.NoError(t, jsonConf.Dump(&out, config.DumpOptions{Format: "yaml", Sources: true}))
+		assert.Contains(t, out.String(), "Amqp1:\n  # source: file\n  float: 1.5\n  # source: file\n  connections: <redacted>\nDefault:\n")
+		assert.Contains(t, out.String(), "  # source: default\n  port: 5666\n")
+
+		out.Reset()
+		assert.NoError(t, jsonConf.Dump(&out, config.DumpOptions{Format: "ini", ShowSecrets: true}))
+		assert.Contains(t, out.String(), "connections = {\"test\":\"a\",\"data_sources\":null}\n")
+
+		// fields tagged as secret are redacted on any level
+		jsonConf = config.NewJSONConfig(JSONConfigMetadata, logger)
+		var connection SecretConnection
+		jsonConf.AddStructured("Amqp1", "Connection", `json:"connection"`, connection)
+		if err := jsonConf.ParseBytes([]byte(`{"Amqp1": {"connection": {"address": "amqp://broker", "credentials": {"user": "guest", "password": "s3cr3t", "token": "dG9rZW4="}, "backups": [{"user": "backup", "password": "p4ss"}]}}}`)); err != nil {
+			t.Fatal(err)
+		}
+		out.Reset()
+		assert.NoError(t, jsonConf.Dump(&out, config.DumpOptions{Format: "ini"}))
+		assert.Contains(t, out.String(), `connection = {"address":"amqp://broker","credentials":{"user":"guest","password":"<redacted>","token":null},"backups":[{"user":"backup","password":"<redacted>","token":null}]}`)
+		out.Reset()
+		assert.NoError(t, jsonConf.Dump(&out, config.DumpOptions{Format: "json"}))
+		assert.NotContains(t, out.String(), "s3cr3t")
+		assert.NotContains(t, out.String(), "p4ss")
+		assert.Contains(t, out.String(), `"password": "<redacted>"`)
+		out.Reset()
+		assert.NoError(t, jsonConf.Dump(&out, config.DumpOptions{Format: "json", ShowSecrets: true}))
+		assert.Contains(t, out.String(), `"password": "s3cr3t"`)
+		// the parsed value is not modified by redaction
+		parsed := jsonConf.Sections["Amqp1"].Options["Connection"].GetStructured().(SecretConnection)
+		assert.Equal(t, "s3cr3t", parsed.Credentials.Password)
+		assert.Equal(t, "p4ss", parsed.Backups[0].Password)
+
+		emptyConf := config.NewJSONConfig(JSONConfigMetadata, logger)
+		emptyConf.AddStructured("Amqp1", "Connection", `json:"connection"`, connection)
+		if err := emptyConf.ParseBytes([]byte(`{}`)); err != nil {
+			t.Fatal(err)
+		}
+		changes, err := config.Diff(emptyConf, jsonConf)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, change := range changes {
+			if change.Option == "Connection" {
+				assert.Equal(t, `~ Amqp1.Connection: {"address":"","credentials":null,"backups":null} -> {"address":"amqp://broker","credentials":{"user":"guest","password":"<redacted>","token":null},"backups":[{"user":"backup","password":"<redacted>","token":null}]}`, change.String())
+			}
+		}
+	})
+
+	t.Run("Test diff of configs", func(t *testing.T) {
+		os.Setenv("DUMP_AMQP1_PASSWORD", "changed")
+		os.Setenv("DUMP_DEFAULT_LOG_LEVEL", "debug")
+		defer os.Unsetenv("DUMP_AMQP1_PASSWORD")
+		defer os.Unsetenv("DUMP_DEFAULT_LOG_LEVEL")
+		newConf := config.NewINIConfig(metadata, logger)
+		newConf.SetEnvPrefix("dump")
+		if err := newConf.Parse(file); err != nil {
+			t.Fatal(err)
+		}
+		changes, err := config.Diff(conf, newConf)
+		if err != nil {
+			t.Fatal(err)
+		}
+		output := []string{}
+		for _, change := range changes {
+			output = append(output, change.String())
+		}
+		assert.Equal(t, []string{
+			"~ amqp1.password: <redacted> -> <redacted>",
+			"~ default.log_level: WARN -> DEBUG",
+		}, output)
+
+		jsonConf := config.NewJSONConfig(JSONConfigMetadata, logger)
+		if err := jsonConf.ParseBytes([]byte(`{}`)); err != nil {
+			t.Fatal(err)
+		}
+		changes, err = config.Diff(conf, jsonConf)
+		assert.NoError(t, err)
+		assert.Equal(t, "+ Amqp1.Float = 6.6", changes[0].String())
+		assert.Equal(t, "- amqp1.channels = 1,2,3", changes[len(changes)-5].String())
+		assert.Equal(t, "- default.log_level = WARN", changes[len(changes)-1].String())
+	})
+}
@@ -0,0 +1,162 @@
+package tests
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path"
+	"testing"
+
+	"github.com/infrawatch/apputils/config"
+	"github.com/infrawatch/apputils/logging"
+	"github.com/stretchr/testify/assert"
+)
+
+type TLSSampleObject struct {
+	CA     string `json:"ca" default:"/nonexistent/pki/ca.pem" validate:"file"`
+	Verify bool   `json:"verify" default:"true"`
+}
+
+type TreeSampleObject struct {
+	Name     string             `json:"name" default:"root"`
+	Children []TreeSampleObject `json:"children"`
+	Parent   *TreeSampleObject  `json:"parent"`
+}
+
+func TestConfigGenerators(t *testing.T) {
+	metadata := map[string][]config.Parameter{
+		"default": []config.Parameter{
+			config.Parameter{Name: "log_file", Tag: `json:"log_file"`, Default: "/var/log/the.log", Description: "Path to the log file", Validators: []config.Validator{}},
+			config.Parameter{Name: "log_level", Tag: `json:"log_level"`, Default: logging.INFO, Deprecated: "use logging.level", Validators: []config.Validator{}},
+		},
+		"amqp1": []config.Parameter{
+			config.Parameter{Name: "connection", Tag: `json:"connection"`, Required: true, Description: "URL of the broker", Validators: []config.Validator{}},
+			config.Parameter{Name: "port", Tag: `json:"port"`, Default: 5666, Validators: []config.Validator{}},
+			config.Parameter{Name: "channels", Tag: `json:"channels"`, Default: []int{1, 2}, Validators: []config.Validator{}},
+		},
+	}
+
+	t.Run("Test generated sample INI file", func(t *testing.T) {
+		var out bytes.Buffer
+		assert.NoError(t, config.GenerateINI(&out, metadata))
+		assert.Equal(t, `[amqp1]
+# URL of the broker
+# required
+connection = 
+port = 5666
+channels = 1,2
+
+[default]
+# Path to the log file
+log_file = /var/log/the.log
+# deprecated: use logging.level
+log_level = INFO
+`, out.String())
+
+		// generated sample has to be parseable by the config itself
+		tmpdir, err := ioutil.TempDir(".", "config_test")
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer os.RemoveAll(tmpdir)
+		file := path.Join(tmpdir, "sample.conf")
+		if err := ioutil.WriteFile(file, out.Bytes(), 0600); err != nil {
+			t.Fatal(err)
+		}
+		logger, err := logging.NewLogger(logging.DEBUG, path.Join(tmpdir, "test.log"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer logger.Destroy()
+		conf := config.NewINIConfig(metadata, logger)
+		conf.SetStrict(config.StrictError)
+		assert.NoError(t, conf.Parse(file))
+	})
+
+	t.Run("Test generated sample JSON file", func(t *testing.T) {
+		var out bytes.Buffer
+		assert.NoError(t, config.GenerateJSON(&out, metadata))
+		assert.JSONEq(t, `{
+			"amqp1": {"connection": null, "port": 5666, "channels": [1, 2]},
+			"default": {"log_file": "/var/log/the.log", "log_level": "INFO"}
+		}`, out.String())
+
+		conf := config.NewJSONConfig(JSONConfigMetadata, nil)
+		var connection ValidatedConnectionObject
+		conf.AddStructured("Amqp1", "Connection", `json:"connection"`, connection)
+		out.Reset()
+		assert.NoError(t, conf.GenerateJSON(&out))
+		assert.JSONEq(t, `{
+			"Amqp1": {
+				"float": 6.6,
+				"connection": {"test": "unset", "timeout": 5000000000, "address": "", "data_sources": null}
+			},
+			"Default": {"log_file": "/var/log/the.log", "NoTag": "notag", "log_level": "INFO", "allow_exec": true, "port": 5666}
+		}`, out.String())
+
+		// validators are not run for defaults, so sample can be generated on any machine
+		conf = config.NewJSONConfig(map[string][]config.Parameter{}, nil)
+		var tls TLSSampleObject
+		conf.AddStructured("amqp1", "TLS", `json:"tls"`, tls)
+		out.Reset()
+		assert.NoError(t, conf.GenerateJSON(&out))
+		assert.JSONEq(t, `{"amqp1": {"tls": {"ca": "/nonexistent/pki/ca.pem", "verify": true}}}`, out.String())
+	})
+
+	t.Run("Test generated JSON Schema", func(t *testing.T) {
+		conf := config.NewJSONConfig(metadata, nil)
+		var connection ValidatedConnectionObject
+		conf.AddStructured("amqp1", "Connection", `json:"connections" required:"true"`, connection)
+		var out bytes.Buffer
+		assert.NoError(t, conf.GenerateJSONSchema(&out))
+
+		var schema map[string]interface{}
+		if err := json.Unmarshal(out.Bytes(), &schema); err != nil {
+			t.Fatal(err)
+		}
+		assert.Equal(t, "http://json-schema.org/draft-07/schema#", schema["$schema"])
+		sections := schema["properties"].(map[string]interface{})
+		amqp1 := sections["amqp1"].(map[string]interface{})
+		assert.Equal(t, []interface{}{"connection", "connections"}, amqp1["required"])
+		params := amqp1["properties"].(map[string]interface{})
+		assert.Equal(t, map[string]interface{}{"description": "URL of the broker"}, params["connection"])
+		assert.Equal(t, map[string]interface{}{"type": "integer", "default": float64(5666)}, params["port"])
+		assert.Equal(t, "array", params["channels"].(map[string]interface{})["type"])
+
+		connSchema := params["connections"].(map[string]interface{})
+		assert.Equal(t, []interface{}{"address"}, connSchema["required"])
+		fields := connSchema["properties"].(map[string]interface{})
+		assert.Equal(t, map[string]interface{}{"type": "string", "format": "uri"}, fields["address"])
+		assert.Equal(t, map[string]interface{}{"type": "string", "default": "unset"}, fields["test"])
+		source := fields["data_sources"].(map[string]interface{})["items"].(map[string]interface{})
+		assert.Equal(t, []interface{}{"url"}, source["required"])
+		sourceFields := source["properties"].(map[string]interface{})
+		assert.Equal(t, []interface{}{"test1", "test3"}, sourceFields["type"].(map[string]interface{})["enum"])
+		assert.Equal(t, map[string]interface{}{"type": "integer", "default": float64(3), "minimum": float64(0), "maximum": float64(10)}, sourceFields["retries"])
+
+		defaults := sections["default"].(map[string]interface{})["properties"].(map[string]interface{})
+		assert.Equal(t, map[string]interface{}{"type": "string", "default": "INFO", "deprecated": true}, defaults["log_level"])
+	})
+	t.Run("Test generated JSON Schema of recursive type", func(t *testing.T) {
+		conf := config.NewJSONConfig(map[string][]config.Parameter{}, nil)
+		var tree TreeSampleObject
+		conf.AddStructured("default", "Tree", `json:"tree"`, tree)
+		var out bytes.Buffer
+		assert.NoError(t, conf.GenerateJSONSchema(&out))
+
+		var schema map[string]interface{}
+		if err := json.Unmarshal(out.Bytes(), &schema); err != nil {
+			t.Fatal(err)
+		}
+		params := schema["properties"].(map[string]interface{})["default"].(map[string]interface{})["properties"].(map[string]interface{})
+		fields := params["tree"].(map[string]interface{})["properties"].(map[string]interface{})
+		assert.Equal(t, map[string]interface{}{"type": "string", "default": "root"}, fields["name"])
+		assert.Equal(t, map[string]interface{}{"type": "array", "items": map[string]interface{}{"type": "object"}}, fields["children"])
+		assert.Equal(t, map[string]interface{}{"type": "object"}, fields["parent"])
+
+		out.Reset()
+		assert.NoError(t, conf.GenerateJSON(&out))
+		assert.JSONEq(t, `{"default": {"tree": {"name": "root", "children": null, "parent": null}}}`, out.String())
+	})
+}
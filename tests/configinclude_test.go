@@ -0,0 +1,140 @@
+package tests
+
+import (
+	"io/ioutil"
+	"os"
+	"path"
+	"testing"
+
+	"github.com/infrawatch/apputils/config"
+	"github.com/infrawatch/apputils/logging"
+	"github.com/stretchr/testify/assert"
+)
+
+func writeFiles(t *testing.T, dir string, files map[string]string) {
+	for name, content := range files {
+		filePath := path.Join(dir, name)
+		if err := os.MkdirAll(path.Dir(filePath), 0700); err != nil {
+			t.Fatal(err)
+		}
+		if err := ioutil.WriteFile(filePath, []byte(content), 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestConfigInclude(t *testing.T) {
+	tmpdir, err := ioutil.TempDir(".", "config_test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmpdir)
+	logger, err := logging.NewLogger(logging.DEBUG, path.Join(tmpdir, "test.log"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer logger.Destroy()
+
+	t.Run("Test INI includes and config directory", func(t *testing.T) {
+		writeFiles(t, tmpdir, map[string]string{
+			"main.ini":            "include = conf.d, extra/*.conf\n\n[default]\nlog_level=error\n",
+			"conf.d/10-base.ini":  "[default]\nlog_file=/var/log/base.log\nlog_level=debug\n\n[amqp1]\nport=5672\n",
+			"conf.d/20-amqp.conf": "[amqp1]\nhost=broker\n",
+			"conf.d/30-skip.txt":  "[amqp1]\nhost=ignored\n",
+			"extra/port.conf":     "[amqp1]\nport=5673\n",
+		})
+		metadata := map[string][]config.Parameter{
+			"default": []config.Parameter{
+				config.Parameter{Name: "log_file", Default: "/var/log/the.log", Validators: []config.Validator{}},
+				config.Parameter{Name: "log_level", Default: "INFO", Validators: []config.Validator{}},
+			},
+			"amqp1": []config.Parameter{
+				config.Parameter{Name: "host", Default: "localhost", Validators: []config.Validator{}},
+				config.Parameter{Name: "port", Default: "5666", Validators: []config.Validator{}},
+			},
+		}
+		conf := config.NewINIConfig(metadata, logger)
+		conf.SetStrict(config.StrictError)
+		if err := conf.Parse(path.Join(tmpdir, "main.ini")); err != nil {
+			t.Fatal(err)
+		}
+		assert.Equal(t, "/var/log/base.log", conf.Sections["default"].Options["log_file"].GetString())
+		assert.Equal(t, "error", conf.Sections["default"].Options["log_level"].GetString())
+		assert.Equal(t, "broker", conf.Sections["amqp1"].Options["host"].GetString())
+		assert.Equal(t, "5673", conf.Sections["amqp1"].Options["port"].GetString())
+
+		conf = config.NewINIConfig(metadata, logger)
+		if err := conf.Parse(path.Join(tmpdir, "conf.d")); err != nil {
+			t.Fatal(err)
+		}
+		assert.Equal(t, "debug", conf.Sections["default"].Options["log_level"].GetString())
+		assert.Equal(t, "broker", conf.Sections["amqp1"].Options["host"].GetString())
+		assert.Equal(t, "5672", conf.Sections["amqp1"].Options["port"].GetString())
+
+		writeFiles(t, tmpdir, map[string]string{
+			"cycle/a.ini": "include = b.ini\n",
+			"cycle/b.ini": "include = a.ini\n",
+			"missing.ini": "include = nothere.ini\n",
+		})
+		err := conf.Parse(path.Join(tmpdir, "cycle/a.ini"))
+		if assert.Error(t, err) {
+			assert.Contains(t, err.Error(), "include cycle detected at")
+		}
+		err = conf.Parse(path.Join(tmpdir, "missing.ini"))
+		if assert.Error(t, err) {
+			assert.Contains(t, err.Error(), "nothere.ini")
+		}
+		if err := os.Mkdir(path.Join(tmpdir, "empty"), 0700); err != nil {
+			t.Fatal(err)
+		}
+		assert.EqualError(t, conf.Parse(path.Join(tmpdir, "empty")), "no config files found in "+path.Join(tmpdir, "empty"))
+	})
+
+	t.Run("Test JSON includes with deep merge", func(t *testing.T) {
+		writeFiles(t, tmpdir, map[string]string{
+			"sensu/config.json":            `{"include": "conf.d", "client": {"name": "main"}}`,
+			"sensu/conf.d/client.json":     `{"client": {"name": "ci", "address": "127.0.0.1", "subscriptions": ["all"]}}`,
+			"sensu/conf.d/rabbitmq.json":   `{"rabbitmq": {"host": "127.0.0.1", "port": 5672, "vhost": "/sensu"}}`,
+			"sensu/conf.d/rabbitmq2.json":  `{"rabbitmq": {"port": 5673}}`,
+			"sensu/conf.d/ignored.yaml":    `rabbitmq: {port: 1}`,
+			"sensu/conf.d/.hidden.json":    `{"rabbitmq": {"port": 2}}`,
+			"sensu/invalid.json":           `{"include": 5}`,
+			"sensu/yaml/config.yaml":       "include: [conf.d/*.yml]\nclient:\n  name: main\n",
+			"sensu/yaml/conf.d/client.yml": "client:\n  address: 10.0.0.1\n",
+		})
+		metadata := map[string][]config.Parameter{
+			"client": []config.Parameter{
+				config.Parameter{Name: "name", Default: "", Validators: []config.Validator{}},
+				config.Parameter{Name: "address", Default: "", Validators: []config.Validator{}},
+			},
+			"rabbitmq": []config.Parameter{
+				config.Parameter{Name: "host", Default: "localhost", Validators: []config.Validator{}},
+				config.Parameter{Name: "port", Default: 5672, Validators: []config.Validator{config.IntValidatorFactory()}},
+				config.Parameter{Name: "vhost", Default: "/", Validators: []config.Validator{}},
+			},
+		}
+		conf := config.NewJSONConfig(metadata, logger)
+		var subscriptions []string
+		conf.AddStructured("client", "Subscriptions", `json:"subscriptions"`, subscriptions)
+		conf.SetStrict(config.StrictError)
+		if err := conf.Parse(path.Join(tmpdir, "sensu/config.json")); err != nil {
+			t.Fatal(err)
+		}
+		assert.Equal(t, "main", conf.Sections["client"].Options["name"].GetString())
+		assert.Equal(t, "127.0.0.1", conf.Sections["client"].Options["address"].GetString())
+		assert.Equal(t, []string{"all"}, conf.Sections["client"].Options["Subscriptions"].GetStructured())
+		assert.Equal(t, "127.0.0.1", conf.Sections["rabbitmq"].Options["host"].GetString())
+		assert.Equal(t, int64(5673), conf.Sections["rabbitmq"].Options["port"].GetInt())
+		assert.Equal(t, "/sensu", conf.Sections["rabbitmq"].Options["vhost"].GetString())
+
+		assert.EqualError(t, conf.Parse(path.Join(tmpdir, "sensu/invalid.json")),
+			"invalid include directive in "+path.Join(tmpdir, "sensu/invalid.json")+": expected string or list of strings")
+
+		yamlConf := config.NewYAMLConfig(metadata, logger)
+		if err := yamlConf.Parse(path.Join(tmpdir, "sensu/yaml/config.yaml")); err != nil {
+			t.Fatal(err)
+		}
+		assert.Equal(t, "main", yamlConf.Sections["client"].Options["name"].GetString())
+		assert.Equal(t, "10.0.0.1", yamlConf.Sections["client"].Options["address"].GetString())
+	})
+}
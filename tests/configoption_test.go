@@ -0,0 +1,130 @@
+package tests
+
+import (
+	"io/ioutil"
+	"os"
+	"path"
+	"testing"
+	"time"
+
+	"github.com/infrawatch/apputils/config"
+	"github.com/infrawatch/apputils/logging"
+	"github.com/stretchr/testify/assert"
+)
+
+var OptionConfigContent = `
+[accessors]
+number=42
+fraction=0.25
+flag=yes
+enabled=true
+interval=1m30s
+items=a, b ,c
+labels=env=prod, team = infra
+numbers=1, 2 ,3
+mixed=1,two,3
+broken=whoops
+`
+
+func TestConfigOptionAccessors(t *testing.T) {
+	tmpdir, err := ioutil.TempDir(".", "config_test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmpdir)
+	file := path.Join(tmpdir, "test.conf")
+	if err := ioutil.WriteFile(file, []byte(OptionConfigContent), 0600); err != nil {
+		t.Fatal(err)
+	}
+	logger, err := logging.NewLogger(logging.DEBUG, path.Join(tmpdir, "test.log"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer logger.Destroy()
+
+	metadata := map[string][]config.Parameter{"accessors": []config.Parameter{}}
+	for _, name := range []string{"number", "fraction", "flag", "enabled", "interval", "items", "labels", "numbers", "mixed", "broken"} {
+		metadata["accessors"] = append(metadata["accessors"], config.Parameter{Name: name, Default: "", Validators: []config.Validator{}})
+	}
+	conf := config.NewINIConfig(metadata, logger)
+	if err := conf.Parse(file); err != nil {
+		t.Fatal(err)
+	}
+	option := func(name string) *config.Option {
+		return conf.Sections["accessors"].Options[name]
+	}
+
+	t.Run("Test conversion of string values", func(t *testing.T) {
+		number, err := option("number").Int()
+		assert.NoError(t, err)
+		assert.Equal(t, int64(42), number)
+		fraction, err := option("fraction").Float()
+		assert.NoError(t, err)
+		assert.Equal(t, 0.25, fraction)
+		enabled, err := option("enabled").Bool()
+		assert.NoError(t, err)
+		assert.True(t, enabled)
+		interval, err := option("interval").Duration()
+		assert.NoError(t, err)
+		assert.Equal(t, 90*time.Second, interval)
+		items, err := option("items").StringSlice()
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"a", "b", "c"}, items)
+		labels, err := option("labels").Map()
+		assert.NoError(t, err)
+		assert.Equal(t, map[string]interface{}{"env": "prod", "team": "infra"}, labels)
+		numbers, err := option("numbers").Ints()
+		assert.NoError(t, err)
+		assert.Equal(t, []int64{1, 2, 3}, numbers)
+
+		assert.Equal(t, int64(42), option("number").MustInt())
+		assert.Equal(t, 90*time.Second, option("interval").MustDuration())
+		assert.Equal(t, []string{"a", "b", "c"}, option("items").MustStringSlice())
+		assert.Equal(t, []int64{1, 2, 3}, option("numbers").MustInts())
+	})
+
+	t.Run("Test errors of failed conversions", func(t *testing.T) {
+		_, err := option("broken").Int()
+		assert.EqualError(t, err, "value (whoops) is not int")
+		_, err = option("broken").Float()
+		assert.EqualError(t, err, "value (whoops) is not float")
+		_, err = option("flag").Bool()
+		assert.EqualError(t, err, "value (yes) is not bool")
+		_, err = option("broken").Duration()
+		assert.EqualError(t, err, "value (whoops) is not duration")
+		_, err = option("broken").Map()
+		assert.EqualError(t, err, "value (whoops) is not list of key=value pairs")
+		_, err = option("mixed").Ints()
+		assert.EqualError(t, err, "item 1: value (two) is not int")
+		assert.Equal(t, []int{1, 0, 3}, option("mixed").GetInts(","))
+
+		assert.Panics(t, func() { option("broken").MustInt() })
+		assert.Panics(t, func() { option("broken").MustBool() })
+		assert.Panics(t, func() { option("fraction").MustDuration() })
+		assert.Panics(t, func() { option("mixed").MustInts() })
+	})
+
+	t.Run("Test conversion of structured values", func(t *testing.T) {
+		conf := config.NewJSONConfig(map[string][]config.Parameter{
+			"accessors": []config.Parameter{
+				config.Parameter{Name: "items", Tag: `json:"items"`, Validators: []config.Validator{}},
+				config.Parameter{Name: "labels", Tag: `json:"labels"`, Validators: []config.Validator{}},
+				config.Parameter{Name: "number", Tag: `json:"number"`, Validators: []config.Validator{}},
+				config.Parameter{Name: "numbers", Tag: `json:"numbers"`, Validators: []config.Validator{}},
+			},
+		}, logger)
+		err := conf.ParseBytes([]byte(`{"accessors": {"items": ["a", 1], "labels": {"a": 1}, "number": 3, "numbers": [1, "2", 3.5]}}`))
+		if err != nil {
+			t.Fatal(err)
+		}
+		section := conf.Sections["accessors"]
+		assert.Equal(t, []string{"a", "1"}, section.Options["items"].MustStringSlice())
+		assert.Equal(t, map[string]interface{}{"a": float64(1)}, section.Options["labels"].MustMap())
+		assert.Equal(t, int64(3), section.Options["number"].MustInt())
+		assert.Equal(t, float64(3), section.Options["number"].MustFloat())
+		_, err = section.Options["number"].Map()
+		assert.Error(t, err)
+		_, err = section.Options["numbers"].Ints()
+		assert.EqualError(t, err, "item 2: value (3.5) is not int")
+	})
+}
@@ -0,0 +1,102 @@
+package tests
+
+import (
+	"io/ioutil"
+	"os"
+	"path"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/infrawatch/apputils/config"
+	"github.com/infrawatch/apputils/logging"
+	"github.com/stretchr/testify/assert"
+)
+
+type ValidatorCase struct {
+	Spec     string
+	Input    interface{}
+	Expected interface{}
+	Error    string
+}
+
+func TestConfigValidators(t *testing.T) {
+	tmpdir, err := ioutil.TempDir(".", "config_test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmpdir)
+	file := path.Join(tmpdir, "test.conf")
+	if err := ioutil.WriteFile(file, []byte("test"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	cases := []ValidatorCase{
+		{"duration", "1m30s", 90 * time.Second, ""},
+		{"duration", int64(1000), time.Microsecond, ""},
+		{"duration", "soon", nil, "value (soon) is not duration"},
+		{"loglevel", "warning", logging.WARN, ""},
+		{"loglevel", "Critical", logging.CRITICAL, ""},
+		{"loglevel", logging.TRACE, logging.TRACE, ""},
+		{"loglevel", "LOUD", nil, "unknown log level: LOUD"},
+		{"bytesize", "512", int64(512), ""},
+		{"bytesize", "10MiB", int64(10 << 20), ""},
+		{"bytesize", "1.5 kb", int64(1500), ""},
+		{"bytesize", "2G", int64(2 << 30), ""},
+		{"bytesize", float64(64), int64(64), ""},
+		{"bytesize", "10 parsecs", nil, "value (10 parsecs) has unknown size unit 'parsecs'"},
+		{"bytesize", "-1", nil, "value (-1) is not byte size"},
+		{"bytesize", "8388608TiB", nil, "value (8388608TiB) is out of range of byte size"},
+		{"url=amqp|amqps", "AMQP://guest@localhost:5672/collectd", "amqp://guest@localhost:5672/collectd", ""},
+		{"url=amqp|amqps", "http://localhost", nil, "value (http://localhost) has scheme 'http' which is not one of allowed schemes: [amqp amqps]"},
+		{"url", "localhost:5672/path", nil, "value (localhost:5672/path) is not URL"},
+		{"url", "/just/path", nil, "value (/just/path) is not URL"},
+		{"hostport", "localhost:5666", "localhost:5666", ""},
+		{"hostport", "::1:5666", nil, "value (::1:5666) is not host:port address"},
+		{"hostport", "[::1]:5666", "[::1]:5666", ""},
+		{"hostport", "localhost:http", nil, "value (localhost:http) has invalid port 'http'"},
+		{"file", file, file, ""},
+		{"file", tmpdir, nil, "value (" + tmpdir + ") is not file"},
+		{"dir", tmpdir + "/./", path.Clean(tmpdir), ""},
+		{"range=1:65535", "5666", int64(5666), ""},
+		{"range=1:65535", 0, nil, "value (0) is out of range <1, 65535>"},
+		{"range=:10", -5, int64(-5), ""},
+		{"range=0:1.5", "0.5", 0.5, ""},
+		{"range=0:1.5", 2, nil, "value (2) is out of range <0, 1.5>"},
+		{"match=^[a-z]+$", "collectd", "collectd", ""},
+		{"match=^[a-z]+$", "Collectd", nil, "value (Collectd) does not match pattern ^[a-z]+$"},
+		{"match=^a{1,3}$", "aaa", "aaa", ""},
+		{"match=^a{1,3}$", "aaaa", nil, "value (aaaa) does not match pattern ^a{1,3}$"},
+		{"regex", "[", nil, "value ([) is not regular expression: error parsing regexp: missing closing ]: `[`"},
+	}
+	for _, test := range cases {
+		validators, err := config.ParseValidators(test.Spec)
+		if !assert.NoError(t, err) || !assert.Len(t, validators, 1) {
+			continue
+		}
+		value, err := validators[0](test.Input)
+		if test.Error != "" {
+			assert.EqualError(t, err, test.Error, test.Spec)
+			continue
+		}
+		assert.NoError(t, err, test.Spec)
+		assert.Equal(t, test.Expected, value, test.Spec)
+	}
+
+	value, err := config.RegexValidatorFactory()("^a.c$")
+	assert.NoError(t, err)
+	assert.True(t, value.(*regexp.Regexp).MatchString("abc"))
+
+	// pattern of match validator takes rest of the specification
+	validators, err := config.ParseValidators("bytesize, match=^[0-9]{1,3},[0-9]{3}$")
+	if assert.NoError(t, err) && assert.Len(t, validators, 2) {
+		value, err := validators[1]("1,000")
+		assert.NoError(t, err)
+		assert.Equal(t, "1,000", value)
+	}
+
+	for _, spec := range []string{"range=5", "range=10:1", "range=a:b", "match=[", "unknown"} {
+		_, err := config.ParseValidators(spec)
+		assert.Error(t, err, spec)
+	}
+}
@@ -0,0 +1,178 @@
+package tests
+
+import (
+	"io/ioutil"
+	"os"
+	"path"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/infrawatch/apputils/config"
+	"github.com/infrawatch/apputils/logging"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestConfigWatcher(t *testing.T) {
+	tmpdir, err := ioutil.TempDir(".", "config_test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmpdir)
+	confpath := path.Join(tmpdir, "test.conf")
+
+	log, err := logging.NewLogger(logging.DEBUG, path.Join(tmpdir, "test.log"))
+	if err != nil {
+		t.Fatalf("Failed to create logger: %s", err)
+	}
+	defer log.Destroy()
+
+	metadata := map[string][]config.Parameter{
+		"default": []config.Parameter{
+			config.Parameter{Name: "log_level", Tag: "", Default: "INFO", Validators: []config.Validator{config.LogLevelValidatorFactory()}},
+		},
+		"amqp1": []config.Parameter{
+			config.Parameter{Name: "host", Tag: "", Default: "localhost", Validators: []config.Validator{}},
+			config.Parameter{Name: "port", Tag: "", Default: 5666, Validators: []config.Validator{config.IntValidatorFactory()}},
+		},
+	}
+	writeConfig := func(content string) {
+		if err := ioutil.WriteFile(confpath, []byte(content), 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	writeConfig("[amqp1]\nport=666\n")
+	conf := config.NewINIConfig(metadata, log)
+	if err := conf.Parse(confpath); err != nil {
+		t.Fatal(err)
+	}
+
+	watcher, err := config.NewWatcher(conf, confpath, log)
+	if err != nil {
+		t.Fatal(err)
+	}
+	notifications := make(chan []config.Change, 10)
+	watcher.Subscribe(func(conf config.Config, changes []config.Change) {
+		notifications <- changes
+	})
+
+	t.Run("Test manual reload", func(t *testing.T) {
+		writeConfig("[default]\nlog_level=ERROR\n[amqp1]\nport=667\n")
+		assert.NoError(t, watcher.Reload())
+
+		changes := <-notifications
+		assert.Equal(t, 2, len(changes))
+		assert.Equal(t, "amqp1", changes[0].Section)
+		assert.Equal(t, "port", changes[0].Option)
+		assert.Equal(t, int64(666), changes[0].Old.GetInt())
+		assert.Equal(t, int64(667), changes[0].New.GetInt())
+		assert.Equal(t, "default", changes[1].Section)
+		assert.Equal(t, "log_level", changes[1].Option)
+		assert.Equal(t, int64(logging.ERROR), changes[1].New.GetInt())
+
+		opt, err := watcher.Current().GetOption("amqp1/port")
+		if assert.NoError(t, err) {
+			assert.Equal(t, int64(667), opt.GetInt())
+		}
+		// the original object stays untouched
+		assert.Equal(t, int64(666), conf.Sections["amqp1"].Options["port"].GetInt())
+	})
+
+	t.Run("Test invalid file keeps current config", func(t *testing.T) {
+		writeConfig("[default]\nlog_level=LOUD\n[amqp1]\nport=668\n")
+		assert.Error(t, watcher.Reload())
+
+		opt, err := watcher.Current().GetOption("amqp1/port")
+		if assert.NoError(t, err) {
+			assert.Equal(t, int64(667), opt.GetInt())
+		}
+		assert.Equal(t, 0, len(notifications))
+	})
+
+	t.Run("Test reload without changes", func(t *testing.T) {
+		writeConfig("[default]\nlog_level=ERROR\n[amqp1]\nport=667\n")
+		assert.NoError(t, watcher.Reload())
+		assert.Equal(t, 0, len(notifications))
+	})
+
+	t.Run("Test reload on file change and signal", func(t *testing.T) {
+		watcher.Start(10*time.Millisecond, syscall.SIGHUP)
+		defer watcher.Stop()
+
+		writeConfig("[default]\nlog_level=ERROR\n[amqp1]\nport=1234\nhost=broker\n")
+		select {
+		case changes := <-notifications:
+			assert.Equal(t, 2, len(changes))
+			assert.Equal(t, "host", changes[0].Option)
+			assert.Equal(t, "broker", changes[0].New.GetString())
+		case <-time.After(5 * time.Second):
+			t.Fatal("Config was not reloaded on file change")
+		}
+
+		// change the file without changing its size and modification time
+		info, err := os.Stat(confpath)
+		if err != nil {
+			t.Fatal(err)
+		}
+		writeConfig("[default]\nlog_level=ERROR\n[amqp1]\nport=4321\nhost=broker\n")
+		os.Chtimes(confpath, info.ModTime(), info.ModTime())
+		syscall.Kill(os.Getpid(), syscall.SIGHUP)
+		select {
+		case changes := <-notifications:
+			assert.Equal(t, 1, len(changes))
+			assert.Equal(t, int64(4321), changes[0].New.GetInt())
+		case <-time.After(5 * time.Second):
+			t.Fatal("Config was not reloaded on signal")
+		}
+	})
+
+	t.Run("Test reload on change of file in config directory", func(t *testing.T) {
+		confdir := path.Join(tmpdir, "conf.d")
+		if err := os.Mkdir(confdir, 0700); err != nil {
+			t.Fatal(err)
+		}
+		writeFile := func(name, content string) {
+			if err := ioutil.WriteFile(path.Join(confdir, name), []byte(content), 0600); err != nil {
+				t.Fatal(err)
+			}
+		}
+		writeFile("10-a.ini", "[amqp1]\nport=1\n")
+		writeFile("20-b.ini", "[amqp1]\nhost=b\n")
+		conf := config.NewINIConfig(metadata, log)
+		if err := conf.Parse(confdir); err != nil {
+			t.Fatal(err)
+		}
+		watcher, err := config.NewWatcher(conf, confdir, log)
+		if err != nil {
+			t.Fatal(err)
+		}
+		notifications := make(chan []config.Change, 10)
+		watcher.Subscribe(func(conf config.Config, changes []config.Change) {
+			notifications <- changes
+		})
+		watcher.Start(10 * time.Millisecond)
+		defer watcher.Stop()
+
+		writeFile("10-a.ini", "[amqp1]\nport=1234\n")
+		select {
+		case changes := <-notifications:
+			if assert.Equal(t, 1, len(changes)) {
+				assert.Equal(t, "port", changes[0].Option)
+				assert.Equal(t, int64(1234), changes[0].New.GetInt())
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatal("Config was not reloaded on change of file in config directory")
+		}
+
+		writeFile("30-c.ini", "[amqp1]\nhost=c\n")
+		select {
+		case changes := <-notifications:
+			if assert.Equal(t, 1, len(changes)) {
+				assert.Equal(t, "host", changes[0].Option)
+				assert.Equal(t, "c", changes[0].New.GetString())
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatal("Config was not reloaded on new file in config directory")
+		}
+	})
+}
@@ -5,9 +5,11 @@ import (
 	"io/ioutil"
 	"log"
 	"net/http"
+	"net/http/httptest"
 	"os"
 	"path"
 	"strconv"
+	"sync/atomic"
 	"testing"
 	"time"
 
@@ -439,6 +441,94 @@ func TestLoki(t *testing.T) {
 			assert.Equal(t, messages[0], message, "Wrong test message when querying for maxWaitTime test results")
 		}
 	})
+
+	t.Run("Test logger sink", func(t *testing.T) {
+		c, err := loki.ConnectLoki(cfg, logger)
+		if err != nil {
+			t.Fatalf("Failed to create loki client: %s", err)
+		}
+		sinkLogger, err := logging.NewLogger(logging.DEBUG, "console")
+		if err != nil {
+			t.Fatalf("Failed to create logger: %s", err)
+		}
+		sinkLogger.SetSink(loki.NewLoggerSink(c, map[string]string{"test": "sink", "unique": testId}, "component"))
+
+		sinkLogger.Metadata(map[string]interface{}{"component": "tests", "foo": "bar"})
+		sinkLogger.Warn("test message sink")
+		// closes the sink which sends the last batch
+		sinkLogger.Destroy()
+
+		queryString := "{test=\"sink\",unique=\"" + testId + "\",level=\"WARN\",component=\"tests\"}"
+		answer, err := c.Query(queryString, 0, batchSize)
+		if err != nil {
+			t.Fatalf("Couldn't query loki after testing logger sink: %s", err)
+		}
+		assert.Equal(t, 1, len(answer), "Query after logger sink test returned wrong count of results")
+		for _, message := range answer {
+			assert.Equal(t, "test message sink foo=\"bar\"", message.Message, "Wrong test message when querying for logger sink test results")
+		}
+	})
+}
+
+func TestLokiSinkWithUnresponsiveServer(t *testing.T) {
+	tmpdir, err := ioutil.TempDir(".", "connector_test_tmp")
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer os.RemoveAll(tmpdir)
+	logger, err := logging.NewLogger(logging.DEBUG, path.Join(tmpdir, "test.log"))
+	if err != nil {
+		t.Fatalf("Failed to create logger: %s", err)
+	}
+	defer logger.Destroy()
+
+	// server which hangs on push until released
+	release := make(chan struct{})
+	var pushed int64
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/ready" {
+			return
+		}
+		<-release
+		var message struct {
+			Streams []loki.LokiStream `json:"streams"`
+		}
+		json.NewDecoder(r.Body).Decode(&message)
+		atomic.AddInt64(&pushed, int64(len(message.Streams)))
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	c, err := loki.CreateLokiConnector(logger, server.URL, time.Hour, 1)
+	if err != nil {
+		t.Fatalf("Failed to create loki client: %s", err)
+	}
+	sink := loki.NewLoggerSink(c, map[string]string{"test": "hung"})
+	sinkLogger, err := logging.NewLogger(logging.DEBUG, "console")
+	if err != nil {
+		t.Fatalf("Failed to create logger: %s", err)
+	}
+	sinkLogger.SetSink(sink)
+
+	// logging is not blocked by hung server, records over the buffer are dropped
+	done := make(chan struct{})
+	go func() {
+		for i := 0; i < 2*loki.SinkBufferSize; i++ {
+			sinkLogger.Info("test message hung")
+		}
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Logging was blocked by unresponsive Loki")
+	}
+	assert.True(t, sink.Dropped() > 0)
+
+	close(release)
+	assert.NoError(t, sink.Close())
+	assert.Equal(t, int64(2*loki.SinkBufferSize), atomic.LoadInt64(&pushed)+sink.Dropped())
+	assert.Error(t, sink.Write(logging.Record{Level: logging.INFO, Message: "after close"}))
 }
 
 func TestSensuCommunication(t *testing.T) {
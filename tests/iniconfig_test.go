@@ -1,6 +1,8 @@
 package tests
 
 import (
+	"bytes"
+	"flag"
 	"fmt"
 	"io/ioutil"
 	"log"
@@ -26,6 +28,7 @@ IntValidator=whoops
 MultiIntValidator=1,2,whoops,4
 BoolValidator=no-way
 OptionsValidator=foo
+LogLevelValidator=LOUD
 `
 
 type ValidatorTest struct {
@@ -91,6 +94,115 @@ func TestINIConfigValues(t *testing.T) {
 	os.Remove(file.Name())
 }
 
+func TestINIConfigEnv(t *testing.T) {
+	tmpdir, err := ioutil.TempDir(".", "config_test")
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer os.RemoveAll(tmpdir)
+	logpath := path.Join(tmpdir, "test.log")
+	file, err := ioutil.TempFile(tmpdir, "test.conf")
+	if err != nil {
+		t.Fatal(err)
+	}
+	file.WriteString(IniConfigContent)
+	err = file.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	log, err := logging.NewLogger(logging.DEBUG, logpath)
+	if err != nil {
+		fmt.Printf("Failed to open log file %s.\n", logpath)
+		os.Exit(2)
+	}
+	defer log.Destroy()
+
+	metadata := map[string][]config.Parameter{
+		"default": []config.Parameter{
+			config.Parameter{Name: "log_file", Tag: "", Default: "/var/log/collectd-sensubility.log", Validators: []config.Validator{}},
+		},
+		"amqp1": []config.Parameter{
+			config.Parameter{Name: "host", Tag: "", Default: "localhost", Validators: []config.Validator{}},
+			config.Parameter{Name: "port", Tag: "", Default: 5666, Validators: []config.Validator{config.IntValidatorFactory()}},
+		},
+		"missing": []config.Parameter{
+			config.Parameter{Name: "connection", Tag: "", Default: "amqp://localhost", Validators: []config.Validator{}},
+		},
+	}
+	os.Setenv("APPUTILS_AMQP1_PORT", "5672")
+	defer os.Unsetenv("APPUTILS_AMQP1_PORT")
+	os.Setenv("APPUTILS_MISSING_CONNECTION", "amqp://broker:5672")
+	defer os.Unsetenv("APPUTILS_MISSING_CONNECTION")
+
+	t.Run("Test values overridden by environment", func(t *testing.T) {
+		conf := config.NewINIConfig(metadata, log)
+		conf.SetEnvPrefix("apputils")
+		if err := conf.Parse(file.Name()); err != nil {
+			t.Fatal(err)
+		}
+		assert.Equal(t, "/var/tmp/test.log", conf.Sections["default"].Options["log_file"].GetString())
+		assert.Equal(t, "localhost", conf.Sections["amqp1"].Options["host"].GetString())
+		assert.Equal(t, int64(5672), conf.Sections["amqp1"].Options["port"].GetInt())
+		assert.Equal(t, "amqp://broker:5672", conf.Sections["missing"].Options["connection"].GetString())
+	})
+
+	t.Run("Test environment ignored without prefix", func(t *testing.T) {
+		conf := config.NewINIConfig(metadata, log)
+		if err := conf.Parse(file.Name()); err != nil {
+			t.Fatal(err)
+		}
+		assert.Equal(t, int64(666), conf.Sections["amqp1"].Options["port"].GetInt())
+		assert.Equal(t, "amqp://localhost", conf.Sections["missing"].Options["connection"].GetString())
+	})
+
+	t.Run("Test validation of environment values", func(t *testing.T) {
+		os.Setenv("APPUTILS_AMQP1_PORT", "whoops")
+		conf := config.NewINIConfig(metadata, log)
+		conf.SetEnvPrefix("apputils")
+		err := conf.Parse(file.Name())
+		if assert.Error(t, err) {
+			assert.Contains(t, err.Error(), "environment variable APPUTILS_AMQP1_PORT")
+		}
+	})
+
+	t.Run("Test values overridden by command-line flags", func(t *testing.T) {
+		os.Setenv("APPUTILS_AMQP1_PORT", "5672")
+		metadata["amqp1"][0].Description = "Hostname of the AMQP1.0 broker"
+		conf := config.NewINIConfig(metadata, log)
+		conf.SetEnvPrefix("apputils")
+		flags := flag.NewFlagSet("test", flag.ContinueOnError)
+		conf.BindFlags(flags)
+		if err := flags.Parse([]string{"-amqp1.port", "5673", "-default.log_file=/tmp/flag.log"}); err != nil {
+			t.Fatal(err)
+		}
+		if err := conf.Parse(file.Name()); err != nil {
+			t.Fatal(err)
+		}
+		assert.Equal(t, "/tmp/flag.log", conf.Sections["default"].Options["log_file"].GetString())
+		assert.Equal(t, "localhost", conf.Sections["amqp1"].Options["host"].GetString())
+		assert.Equal(t, int64(5673), conf.Sections["amqp1"].Options["port"].GetInt())
+		assert.Equal(t, "amqp://broker:5672", conf.Sections["missing"].Options["connection"].GetString())
+
+		var help bytes.Buffer
+		flags.SetOutput(&help)
+		flags.PrintDefaults()
+		assert.Contains(t, help.String(), "-amqp1.host string\n    \tHostname of the AMQP1.0 broker (env APPUTILS_AMQP1_HOST) (default \"localhost\")\n")
+		assert.Contains(t, help.String(), "-amqp1.port string\n    \tvalue of parameter port in section amqp1 (env APPUTILS_AMQP1_PORT) (default \"5666\")\n")
+
+		if err := flags.Parse([]string{"-amqp1.port", "whoops"}); err != nil {
+			t.Fatal(err)
+		}
+		err := conf.Parse(file.Name())
+		if assert.Error(t, err) {
+			assert.Contains(t, err.Error(), "flag -amqp1.port")
+		}
+	})
+
+	assert.Equal(t, "APPUTILS_AMQP1_LOG_FILE", config.EnvVarName("apputils", "amqp1", "log-file"))
+	assert.Equal(t, "AMQP1_PORT", config.EnvVarName("", "amqp1", "port"))
+}
+
 func TestValidators(t *testing.T) {
 	// create temporary config file
 	tmpdir, err := ioutil.TempDir(".", "config_test")
@@ -124,6 +236,7 @@ func TestValidators(t *testing.T) {
 			ValidatorTest{"MultiIntValidator", config.MultiIntValidatorFactory(","), "1,2"},
 			ValidatorTest{"BoolValidator", config.BoolValidatorFactory(), "true"},
 			ValidatorTest{"OptionsValidator", config.StringOptionsValidatorFactory([]string{"bar", "baz"}), "bar"},
+			ValidatorTest{"LogLevelValidator", config.LogLevelValidatorFactory(), "INFO"},
 		}
 		for _, test := range tests {
 			metadata := map[string][]config.Parameter{
@@ -151,6 +264,88 @@ func TestValidators(t *testing.T) {
 		}
 	})
 
+	t.Run("Test of required parameters and section validators", func(t *testing.T) {
+		metadata := map[string][]config.Parameter{
+			"default": []config.Parameter{
+				config.Parameter{Name: "log_file", Tag: "", Default: "/var/log/the.log", Required: true, Deprecated: "use [logging] file instead", Validators: []config.Validator{}},
+				config.Parameter{Name: "log_level", Tag: "", Default: "INFO", Validators: []config.Validator{config.LogLevelValidatorFactory()}},
+			},
+			"socket": []config.Parameter{
+				config.Parameter{Name: "in_address", Tag: "", Validators: []config.Validator{}},
+				config.Parameter{Name: "out_address", Tag: "", Validators: []config.Validator{}},
+			},
+			"invalid": []config.Parameter{
+				config.Parameter{Name: "IntValidator", Tag: "", Default: "1", Validators: []config.Validator{config.IntValidatorFactory()}},
+				config.Parameter{Name: "BoolValidator", Tag: "", Default: "true", Validators: []config.Validator{config.BoolValidatorFactory()}},
+				config.Parameter{Name: "missing", Tag: "", Default: "whatever", Required: true, Validators: []config.Validator{}},
+			},
+		}
+		conf := config.NewINIConfig(metadata, log)
+		conf.AddSectionValidator("socket", config.RequireOneOf("in_address", "out_address"))
+		err := conf.Parse(file.Name())
+		if assert.Error(t, err) {
+			errs, ok := err.(config.ValidationErrors)
+			if assert.True(t, ok) && assert.Len(t, errs, 4) {
+				assert.EqualError(t, errs[0], "failed validation for parameter IntValidator: value (whoops) is not int")
+				assert.EqualError(t, errs[1], "failed validation for parameter BoolValidator: value (no-way) is not bool")
+				assert.EqualError(t, errs[2], "missing required parameter missing in section invalid")
+				assert.EqualError(t, errs[3], "failed validation of section socket: at least one of parameters in_address, out_address has to be set")
+			}
+			assert.Contains(t, err.Error(), "4 errors in configuration: ")
+		}
+		// valid parameters are parsed despite errors in other ones
+		assert.Equal(t, "/var/tmp/test.log", conf.Sections["default"].Options["log_file"].GetString())
+
+		delete(metadata, "invalid")
+		conf = config.NewINIConfig(metadata, log)
+		conf.AddSectionValidator("socket", config.RequireOneOf("in_address", "out_address"))
+		os.Setenv("APPUTILS_SOCKET_OUT_ADDRESS", "/tmp/socket")
+		defer os.Unsetenv("APPUTILS_SOCKET_OUT_ADDRESS")
+		conf.SetEnvPrefix("apputils")
+		assert.NoError(t, conf.Parse(file.Name()))
+
+		content, err := ioutil.ReadFile(logpath)
+		if err != nil {
+			t.Fatal(err)
+		}
+		assert.Contains(t, string(content), "deprecated configuration parameter is set.")
+		assert.Contains(t, string(content), "hint: use [logging] file instead")
+	})
+
+	t.Run("Test of strict mode", func(t *testing.T) {
+		metadata := map[string][]config.Parameter{
+			"default": []config.Parameter{
+				config.Parameter{Name: "log_file", Tag: "", Default: "/var/log/the.log", Validators: []config.Validator{}},
+				config.Parameter{Name: "allow_execs", Tag: "", Default: "true", Validators: []config.Validator{}},
+			},
+			"amqp": []config.Parameter{
+				config.Parameter{Name: "port", Tag: "", Default: "5666", Validators: []config.Validator{}},
+			},
+		}
+		conf := config.NewINIConfig(metadata, log)
+		assert.NoError(t, conf.Parse(file.Name()))
+
+		conf.SetStrict(config.StrictError)
+		err := conf.Parse(file.Name())
+		if assert.Error(t, err) {
+			errs := err.(config.ValidationErrors)
+			if assert.Len(t, errs, 3) {
+				assert.EqualError(t, errs[0], "unknown section 'amqp1' (did you mean 'amqp'?)")
+				assert.EqualError(t, errs[1], "unknown option 'allow_exec' in section 'default' (did you mean 'allow_execs'?)")
+				assert.EqualError(t, errs[2], "unknown section 'invalid'")
+			}
+		}
+
+		conf.SetStrict(config.StrictWarn)
+		assert.NoError(t, conf.Parse(file.Name()))
+		content, err := ioutil.ReadFile(logpath)
+		if err != nil {
+			t.Fatal(err)
+		}
+		assert.Contains(t, string(content), "[WARN] unknown configuration value.")
+		assert.Contains(t, string(content), "unknown option 'allow_exec' in section 'default' (did you mean 'allow_execs'?)")
+	})
+
 	t.Run("Test of fetching option dynamically", func(t *testing.T) {
 		metadata := map[string][]config.Parameter{
 			"default": []config.Parameter{
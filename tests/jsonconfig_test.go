@@ -1,12 +1,14 @@
 package tests
 
 import (
+	"flag"
 	"fmt"
 	"io/ioutil"
 	"log"
 	"os"
 	"path"
 	"testing"
+	"time"
 
 	"github.com/infrawatch/apputils/config"
 	"github.com/infrawatch/apputils/logging"
@@ -23,6 +25,19 @@ type OuterTestObject struct {
 	Connections []InnerTestObject `json:"data_sources"`
 }
 
+type ValidatedSourceObject struct {
+	Type    string `json:"type" validate:"options=test1|test3"`
+	URL     string `json:"url" required:"true"`
+	Retries int    `json:"retries" default:"3" validate:"range=0:10"`
+}
+
+type ValidatedConnectionObject struct {
+	Test    string                  `json:"test" default:"unset"`
+	Timeout time.Duration           `json:"timeout" default:"5s" validate:"duration"`
+	Address string                  `json:"address" required:"true" validate:"url=amqp"`
+	Sources []ValidatedSourceObject `json:"data_sources"`
+}
+
 type DynamicFetchTest struct {
 	AddrStr       string
 	ExpectedValue string
@@ -127,6 +142,78 @@ func TestJSONConfigValues(t *testing.T) {
 		assert.Equal(t, parsedConnections, connTypedObj.Connections, "Did not parse correctly")
 	})
 
+	t.Run("Test validation of structured values", func(t *testing.T) {
+		conf := config.NewJSONConfig(JSONConfigMetadata, log)
+		var connections ValidatedConnectionObject
+		conf.AddStructured("Amqp1", "Connection", `json:"connections"`, connections)
+		err = conf.Parse(file.Name())
+		if assert.Error(t, err) {
+			errs := err.(config.ValidationErrors)
+			if assert.Len(t, errs, 2) {
+				assert.EqualError(t, errs[0], "missing required parameter Amqp1.Connection.Address")
+				assert.EqualError(t, errs[1], "failed validation for parameter Amqp1.Connection.Sources[1].Type: value (test2) is not one of allowed options: [test1 test3]")
+			}
+		}
+
+		conf = config.NewJSONConfig(JSONConfigMetadata, log)
+		conf.AddStructured("Amqp1", "Connection", `json:"connection"`, connections)
+		conf.AddStructured("Amqp1", "Missing", `json:"missing"`, connections)
+		err = conf.ParseBytes([]byte(`{"Amqp1": {"connection": {"address": "AMQP://localhost", "data_sources": [{"type": "test1", "url": "a"}, {"type": "test3", "url": "b", "retries": 11}]}}}`))
+		if assert.Error(t, err) {
+			assert.EqualError(t, err, "failed validation for parameter Amqp1.Connection.Sources[1].Retries: value (11) is out of range <0, 10>")
+		}
+
+		conf = config.NewJSONConfig(JSONConfigMetadata, log)
+		conf.AddStructured("Amqp1", "Connection", `json:"connection"`, connections)
+		conf.AddStructured("Amqp1", "Required", `json:"required" required:"true"`, connections)
+		err = conf.ParseBytes([]byte(`{"Amqp1": {"connection": {"address": "AMQP://localhost", "timeout": 60000000000, "data_sources": [{"type": "test1", "url": "a"}]}}}`))
+		assert.EqualError(t, err, "missing required parameter Amqp1.Required")
+
+		conf = config.NewJSONConfig(JSONConfigMetadata, log)
+		conf.AddStructured("Amqp1", "Connection", `json:"connection"`, connections)
+		conf.AddStructured("Amqp1", "Missing", `json:"missing"`, connections)
+		err = conf.ParseBytes([]byte(`{"Amqp1": {"connection": {"address": "AMQP://localhost", "timeout": 60000000000, "data_sources": [{"type": "test1", "url": "a"}]}}}`))
+		if err != nil {
+			t.Fatal(err)
+		}
+		parsed := conf.Sections["Amqp1"].Options["Connection"].GetStructured().(ValidatedConnectionObject)
+		assert.Equal(t, "unset", parsed.Test)
+		assert.Equal(t, time.Minute, parsed.Timeout)
+		assert.Equal(t, "amqp://localhost", parsed.Address)
+		assert.Equal(t, []ValidatedSourceObject{{Type: "test1", URL: "a", Retries: 3}}, parsed.Sources)
+		// required fields of missing parameter are not checked, defaults are applied
+		missing := conf.Sections["Amqp1"].Options["Missing"].GetStructured().(ValidatedConnectionObject)
+		assert.Equal(t, ValidatedConnectionObject{Test: "unset", Timeout: 5 * time.Second}, missing)
+
+		// keys are matched case-insensitively like by the JSON decoder
+		conf = config.NewJSONConfig(JSONConfigMetadata, log)
+		conf.AddStructured("Amqp1", "Connection", `json:"connection"`, connections)
+		err = conf.ParseBytes([]byte(`{"amqp1": {"Connection": {"Address": "amqp://fromfile", "TEST": "fromfile"}}}`))
+		if err != nil {
+			t.Fatal(err)
+		}
+		opt := conf.Sections["Amqp1"].Options["Connection"]
+		parsed = opt.GetStructured().(ValidatedConnectionObject)
+		assert.Equal(t, "amqp://fromfile", parsed.Address)
+		assert.Equal(t, "fromfile", parsed.Test)
+		assert.Equal(t, "file", opt.Source())
+	})
+
+	t.Run("Test of strict mode", func(t *testing.T) {
+		conf := config.NewJSONConfig(JSONConfigMetadata, log)
+		conf.SetStrict(config.StrictError)
+		err = conf.Parse(file.Name())
+		assert.EqualError(t, err, "unknown option 'connections' in section 'Amqp1'")
+
+		var connections OuterTestObject
+		conf.AddStructured("Amqp1", "Connections", `json:"connections"`, connections)
+		assert.NoError(t, conf.Parse(file.Name()))
+		err = conf.ParseBytes([]byte(`{"Default": {"log_fil": "/tmp/log"}, "Amqp": {}}`))
+		assert.EqualError(t, err, "2 errors in configuration: unknown section 'Amqp' (did you mean 'Amqp1'?); unknown option 'log_fil' in section 'Default' (did you mean 'log_file'?)")
+		err = conf.ParseBytes([]byte(`{"Amqp1": {"connections": {"tset": "x", "data_sources": [{"type": "test1", "URL": "u", "urls": "v"}]}}, "version": 2}`))
+		assert.EqualError(t, err, "3 errors in configuration: unknown top-level option 'version'; unknown field 'urls' of parameter Amqp1.Connections.Connections[0] (did you mean 'url'?); unknown field 'tset' of parameter Amqp1.Connections (did you mean 'test'?)")
+	})
+
 	t.Run("Test of fetching option dynamically", func(t *testing.T) {
 		conf := config.NewJSONConfig(JSONConfigMetadata, log)
 		var connections OuterTestObject
@@ -148,4 +235,59 @@ func TestJSONConfigValues(t *testing.T) {
 			}
 		}
 	})
+
+	t.Run("Test values overridden by environment", func(t *testing.T) {
+		env := map[string]string{
+			"TEST_DEFAULT_LOG_LEVEL":   "ERROR",
+			"TEST_DEFAULT_PORT":        "4321",
+			"TEST_DEFAULT_ALLOW_EXEC":  "false",
+			"TEST_AMQP1_CONNECTIONS":   `{"test": "fromenv", "data_sources": [{"type": "env", "url": "localhost"}]}`,
+			"TEST_DEFAULT_NOTAG":       "123",
+			"TEST_DEFAULT_UNKNOWN_KEY": "ignored",
+		}
+		for key, value := range env {
+			os.Setenv(key, value)
+			defer os.Unsetenv(key)
+		}
+
+		conf := config.NewJSONConfig(JSONConfigMetadata, log)
+		conf.SetEnvPrefix("test")
+		var connections OuterTestObject
+		conf.AddStructured("Amqp1", "Connections", `json:"connections"`, connections)
+		err = conf.Parse(file.Name())
+		if err != nil {
+			t.Fatal(err)
+		}
+		assert.Equal(t, "ERROR", conf.Sections["Default"].Options["LogLevel"].GetString())
+		assert.Equal(t, int64(4321), conf.Sections["Default"].Options["Port"].GetInt())
+		assert.Equal(t, false, conf.Sections["Default"].Options["AllowExec"].GetBool())
+		assert.Equal(t, "123", conf.Sections["Default"].Options["NoTag"].GetString())
+		assert.Equal(t, "/var/log/another.log", conf.Sections["Default"].Options["LogFile"].GetString())
+		connTypedObj := conf.Sections["Amqp1"].Options["Connections"].GetStructured().(OuterTestObject)
+		assert.Equal(t, "fromenv", connTypedObj.Test)
+		assert.Equal(t, []InnerTestObject{InnerTestObject{"env", "localhost"}}, connTypedObj.Connections)
+
+		flags := flag.NewFlagSet("test", flag.ContinueOnError)
+		conf.BindFlags(flags)
+		err = flags.Parse([]string{"-Default.log_level=INFO", "-Amqp1.float", "7.5", "-Amqp1.connections", `{"test": "fromflag"}`})
+		if err != nil {
+			t.Fatal(err)
+		}
+		err = conf.Parse(file.Name())
+		if err != nil {
+			t.Fatal(err)
+		}
+		assert.Equal(t, "INFO", conf.Sections["Default"].Options["LogLevel"].GetString())
+		assert.Equal(t, int64(4321), conf.Sections["Default"].Options["Port"].GetInt())
+		assert.Equal(t, float64(7.5), conf.Sections["Amqp1"].Options["Float"].GetFloat())
+		assert.Equal(t, "fromflag", conf.Sections["Amqp1"].Options["Connections"].GetStructured().(OuterTestObject).Test)
+
+		conf = config.NewJSONConfig(JSONConfigMetadata, log)
+		conf.SetEnvPrefix("test")
+		os.Setenv("TEST_DEFAULT_LOG_LEVEL", "LOUD")
+		err = conf.Parse(file.Name())
+		if assert.Error(t, err) {
+			assert.Contains(t, err.Error(), "environment variable TEST_DEFAULT_LOG_LEVEL")
+		}
+	})
 }
@@ -1,13 +1,24 @@
 package tests
 
 import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
 	"fmt"
 	"io"
 	"io/ioutil"
 	"log"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
 	"os"
 	"path"
+	"path/filepath"
+	"strings"
+	"sync"
 	"testing"
+	"time"
 
 	"github.com/infrawatch/apputils/logging"
 	"github.com/stretchr/testify/assert"
@@ -45,6 +56,21 @@ func getLastLineWithSeek(filepath string) (string, error) {
 	return line, nil
 }
 
+type testSink struct {
+	records []logging.Record
+	closed  bool
+}
+
+func (s *testSink) Write(record logging.Record) error {
+	s.records = append(s.records, record)
+	return nil
+}
+
+func (s *testSink) Close() error {
+	s.closed = true
+	return nil
+}
+
 func TestLogger(t *testing.T) {
 	// create temporary logging directory
 	tmpdir, err := ioutil.TempDir(".", "logging_test")
@@ -246,6 +272,37 @@ func TestLogger(t *testing.T) {
 		assert.Equal(t, "[ERROR] Test SetFile\n", actualInFile2)
 	})
 
+	t.Run("Test SetFile and SetConsole keep added sinks", func(t *testing.T) {
+		logger, err := logging.NewLogger(logging.INFO, logpath2)
+		if err != nil {
+			t.Fatalf("Failed to create logger: %s", err)
+		}
+		sink := &testSink{}
+		logger.AddSink(sink, logging.WARN)
+
+		err = logger.SetFile(path.Join(tmpdir, "test3.log"), 0666)
+		if err != nil {
+			t.Fatalf("Failed switching log files: %s", err)
+		}
+		logger.Warn("Test SetFile with added sink")
+		logger.SetConsole()
+		logger.Error("Test SetConsole with added sink")
+		assert.False(t, sink.closed)
+		if assert.Len(t, sink.records, 2) {
+			assert.Equal(t, "Test SetFile with added sink", sink.records[0].Message)
+			assert.Equal(t, "Test SetConsole with added sink", sink.records[1].Message)
+		}
+		actual, err := getLastLineWithSeek(path.Join(tmpdir, "test3.log"))
+		if err != nil {
+			t.Fatalf("Failed to fetch last line in log file: %s", err)
+		}
+		assert.Equal(t, "[WARN] Test SetFile with added sink\n", actual)
+
+		logger.SetSink(logging.NewStdoutSink())
+		assert.True(t, sink.closed)
+		logger.Destroy()
+	})
+
 	t.Run("Test metadata", func(t *testing.T) {
 		log.Metadata(map[string]interface{}{"foo": "bar", "baz": []string{"bam", "vam"}})
 		log.Error("Test metadata 1")
@@ -265,4 +322,756 @@ func TestLogger(t *testing.T) {
 		}
 		assert.Regexp(t, `[0-9]{4}\-[0-9]{2}-[0-9]{2} [0-9]{2}:[0-9]{2}:[0-9]{2} \[ERROR\] Test timestamp\n`, actual)
 	})
+
+	t.Run("Test SetSink", func(t *testing.T) {
+		log.Level = logging.INFO
+		lastInFile, err := getLastLineWithSeek(logpath)
+		if err != nil {
+			t.Fatalf("Failed to fetch last line in log file: %s", err)
+		}
+
+		sink := &testSink{}
+		log.SetSink(sink)
+		log.Metadata(map[string]interface{}{"foo": "bar"})
+		log.Warn("Test sink")
+		log.Debug("Test sink debug")
+
+		assert.Equal(t, 1, len(sink.records))
+		assert.Equal(t, logging.WARN, sink.records[0].Level)
+		assert.Equal(t, "Test sink", sink.records[0].Message)
+		assert.Equal(t, logging.Metadata{"foo": "bar"}, sink.records[0].Metadata)
+		assert.False(t, sink.records[0].Time.IsZero())
+
+		actual, err := getLastLineWithSeek(logpath)
+		if err != nil {
+			t.Fatalf("Failed to fetch last line in log file: %s", err)
+		}
+		assert.Equal(t, lastInFile, actual)
+
+		err = log.SetFile(logpath, 0666)
+		if err != nil {
+			t.Fatalf("Failed switching log files: %s", err)
+		}
+		assert.True(t, sink.closed)
+	})
+
+	t.Run("Test AddSink", func(t *testing.T) {
+		log.Level = logging.DEBUG
+		log.Timestamp = false
+		var buffer bytes.Buffer
+		sink := &testSink{}
+		log.SetSink(logging.NewWriterSink(&buffer))
+		log.AddSink(sink, logging.WARN)
+
+		log.Debug("Test AddSink 1")
+		log.Error("Test AddSink 2")
+
+		assert.Equal(t, "[DEBUG] Test AddSink 1\n[ERROR] Test AddSink 2\n", buffer.String())
+		assert.Equal(t, 1, len(sink.records))
+		assert.Equal(t, "Test AddSink 2", sink.records[0].Message)
+
+		log.Destroy()
+		assert.True(t, sink.closed)
+	})
+}
+
+func TestLoggerFormatters(t *testing.T) {
+	var buffer bytes.Buffer
+	log, err := logging.NewLogger(logging.DEBUG, "console")
+	if err != nil {
+		t.Fatalf("Failed to create logger: %s", err)
+	}
+	log.SetSink(logging.NewWriterSink(&buffer))
+	defer log.Destroy()
+
+	t.Run("Test JSON formatter", func(t *testing.T) {
+		buffer.Reset()
+		log.SetFormatter(logging.JSONFormatter{OmitTime: true})
+		log.Metadata(map[string]interface{}{"foo": "bar", "baz": []string{"bam", "vam"}, "count": 3, "level": "x"})
+		log.Info("Test \"json\"")
+		assert.Equal(t, `{"level":"INFO","message":"Test \"json\"","baz":["bam","vam"],"count":3,"foo":"bar","metadata.level":"x"}`+"\n", buffer.String())
+
+		buffer.Reset()
+		log.Timestamp = true
+		log.Warn("Test json timestamp")
+		log.Timestamp = false
+		assert.Regexp(t, `^\{"time":"[0-9]{4}-[0-9]{2}-[0-9]{2}T[^"]+","level":"WARN","message":"Test json timestamp"\}\n$`, buffer.String())
+
+		buffer.Reset()
+		log.SetFormatter(logging.JSONFormatter{})
+		log.Warn("Test json default timestamp")
+		assert.Regexp(t, `^\{"time":"[0-9]{4}-[0-9]{2}-[0-9]{2}T[^"]+","level":"WARN","message":"Test json default timestamp"\}\n$`, buffer.String())
+	})
+
+	t.Run("Test logfmt formatter", func(t *testing.T) {
+		buffer.Reset()
+		log.SetFormatter(logging.LogfmtFormatter{})
+		log.Metadata(map[string]interface{}{"foo": "bar", "characters read": 0, "error": "no way"})
+		log.Error("Test logfmt")
+		assert.Equal(t, "level=ERROR msg=\"Test logfmt\" characters_read=0 error=\"no way\" foo=bar\n", buffer.String())
+	})
+
+	t.Run("Test text formatter", func(t *testing.T) {
+		buffer.Reset()
+		log.SetFormatter(logging.TextFormatter{})
+		log.Metadata(map[string]interface{}{"foo": "bar", "baz": "bam"})
+		log.Debug("Test text")
+		assert.Equal(t, "[DEBUG] Test text [baz: bam, foo: bar]\n", buffer.String())
+	})
+}
+
+func TestLoggerContext(t *testing.T) {
+	sink := &testSink{}
+	log, err := logging.NewLogger(logging.DEBUG, "console")
+	if err != nil {
+		t.Fatalf("Failed to create logger: %s", err)
+	}
+	log.SetSink(sink)
+	defer log.Destroy()
+
+	t.Run("Test With and per-call metadata", func(t *testing.T) {
+		sink.records = nil
+		child := log.With(logging.Metadata{"component": "test", "foo": "bar"})
+		child.Info("Test With", logging.Metadata{"foo": "baz", "call": 1})
+		log.Info("Test parent")
+
+		assert.Equal(t, 2, len(sink.records))
+		assert.Equal(t, logging.Metadata{"component": "test", "foo": "baz", "call": 1}, sink.records[0].Metadata)
+		assert.Equal(t, logging.Metadata{}, sink.records[1].Metadata)
+	})
+
+	t.Run("Test Metadata is consumed by filtered record", func(t *testing.T) {
+		sink.records = nil
+		log.SetLogLevel(logging.INFO)
+		log.Metadata(map[string]interface{}{"foo": "bar"})
+		log.Debug("Test filtered")
+		log.Info("Test not filtered")
+		log.SetLogLevel(logging.DEBUG)
+
+		assert.Equal(t, 1, len(sink.records))
+		assert.Equal(t, logging.Metadata{}, sink.records[0].Metadata)
+	})
+
+	t.Run("Test concurrent logging", func(t *testing.T) {
+		var buffer bytes.Buffer
+		log.SetSink(logging.NewWriterSink(&buffer))
+		log.SetFormatter(logging.LogfmtFormatter{})
+
+		var wg sync.WaitGroup
+		for i := 0; i < 10; i++ {
+			wg.Add(1)
+			go func(id int) {
+				defer wg.Done()
+				child := log.With(logging.Metadata{"goroutine": id})
+				for j := 0; j < 100; j++ {
+					child.Debug(fmt.Sprintf("goroutine-%d", id), logging.Metadata{"iteration": j})
+				}
+			}(i)
+		}
+		wg.Wait()
+
+		lines := strings.Split(strings.TrimSpace(buffer.String()), "\n")
+		assert.Equal(t, 1000, len(lines))
+		for _, line := range lines {
+			var id, iteration int
+			var msgID int
+			_, err := fmt.Sscanf(line, "level=DEBUG msg=goroutine-%d goroutine=%d iteration=%d", &msgID, &id, &iteration)
+			assert.NoError(t, err)
+			assert.Equal(t, msgID, id, "Metadata attached to a wrong record: %s", line)
+		}
+	})
+}
+
+func TestLoggerRotation(t *testing.T) {
+	tmpdir, err := ioutil.TempDir(".", "logging_test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmpdir)
+	logpath := path.Join(tmpdir, "rotated.log")
+
+	log, err := logging.NewLogger(logging.DEBUG, "console")
+	if err != nil {
+		t.Fatalf("Failed to create logger: %s", err)
+	}
+	defer log.Destroy()
+
+	t.Run("Test size based rotation", func(t *testing.T) {
+		sink, err := logging.NewRotatingFileSink(logpath, 0666, logging.RotationOptions{MaxSize: 30, MaxBackups: 2})
+		if err != nil {
+			t.Fatalf("Failed to create rotating sink: %s", err)
+		}
+		log.SetSink(sink)
+		// every record is 24 bytes long, so each one ends up in separate file
+		for i := 0; i < 4; i++ {
+			log.Info(fmt.Sprintf("Test rotation %d", i))
+		}
+
+		actual, err := ioutil.ReadFile(logpath)
+		assert.NoError(t, err)
+		assert.Equal(t, "[INFO] Test rotation 3\n", string(actual))
+		actual, err = ioutil.ReadFile(logpath + ".1")
+		assert.NoError(t, err)
+		assert.Equal(t, "[INFO] Test rotation 2\n", string(actual))
+		actual, err = ioutil.ReadFile(logpath + ".2")
+		assert.NoError(t, err)
+		assert.Equal(t, "[INFO] Test rotation 1\n", string(actual))
+		_, err = os.Stat(logpath + ".3")
+		assert.True(t, os.IsNotExist(err))
+	})
+
+	t.Run("Test compression of rotated files", func(t *testing.T) {
+		sink, err := logging.NewRotatingFileSink(logpath, 0666, logging.RotationOptions{MaxSize: 30, Compress: true})
+		if err != nil {
+			t.Fatalf("Failed to create rotating sink: %s", err)
+		}
+		log.SetSink(sink)
+		log.Info("Test compression")
+		// closing the sink waits for compression running in background
+		log.SetSink(logging.NewWriterSink(ioutil.Discard))
+		rotated, err := filepath.Glob(logpath + ".*.rotated")
+		assert.NoError(t, err)
+		assert.Empty(t, rotated)
+
+		file, err := os.Open(logpath + ".1.gz")
+		if err != nil {
+			t.Fatalf("Failed to open compressed file: %s", err)
+		}
+		defer file.Close()
+		reader, err := gzip.NewReader(file)
+		if err != nil {
+			t.Fatalf("Failed to read compressed file: %s", err)
+		}
+		actual, err := ioutil.ReadAll(reader)
+		assert.NoError(t, err)
+		assert.Equal(t, "[INFO] Test rotation 3\n", string(actual))
+	})
+
+	t.Run("Test failure of compression", func(t *testing.T) {
+		failpath := path.Join(tmpdir, "failing.log")
+		// directory in place of the backup makes the compression fail
+		if err := os.MkdirAll(path.Join(failpath+".1.gz", "blocker"), 0755); err != nil {
+			t.Fatal(err)
+		}
+		sink, err := logging.NewRotatingFileSink(failpath, 0666, logging.RotationOptions{MaxSize: 30, MaxBackups: 1, Compress: true})
+		if err != nil {
+			t.Fatalf("Failed to create rotating sink: %s", err)
+		}
+		log.SetSink(sink)
+		assert.NoError(t, log.Info("Test failed compression 1"))
+		assert.NoError(t, log.Info("Test failed compression 2"))
+		// the next rotation reports failure of the previous compression, but the record is written
+		err = log.Info("Test failed compression 3")
+		if assert.Error(t, err) {
+			assert.Contains(t, err.Error(), "failed to rotate log file")
+		}
+		log.SetSink(logging.NewWriterSink(ioutil.Discard))
+
+		actual, err := ioutil.ReadFile(failpath)
+		assert.NoError(t, err)
+		assert.Equal(t, "[INFO] Test failed compression 3\n", string(actual))
+		// rotated files are kept uncompressed
+		rotated, err := filepath.Glob(failpath + ".*.rotated")
+		assert.NoError(t, err)
+		if assert.Len(t, rotated, 2) {
+			for i, file := range rotated {
+				actual, err := ioutil.ReadFile(file)
+				assert.NoError(t, err)
+				assert.Equal(t, fmt.Sprintf("[INFO] Test failed compression %d\n", i+1), string(actual))
+			}
+		}
+	})
+
+	t.Run("Test age based rotation of existing file", func(t *testing.T) {
+		agedpath := path.Join(tmpdir, "aged.log")
+		if err := ioutil.WriteFile(agedpath, []byte("[INFO] Test old record\n"), 0666); err != nil {
+			t.Fatal(err)
+		}
+		// age of the file is kept across restarts
+		old := time.Now().Add(-2 * time.Hour)
+		if err := os.Chtimes(agedpath, old, old); err != nil {
+			t.Fatal(err)
+		}
+		sink, err := logging.NewRotatingFileSink(agedpath, 0666, logging.RotationOptions{MaxAge: time.Hour})
+		if err != nil {
+			t.Fatalf("Failed to create rotating sink: %s", err)
+		}
+		log.SetSink(sink)
+		log.Info("Test new record")
+
+		actual, err := ioutil.ReadFile(agedpath)
+		assert.NoError(t, err)
+		assert.Equal(t, "[INFO] Test new record\n", string(actual))
+		actual, err = ioutil.ReadFile(agedpath + ".1")
+		assert.NoError(t, err)
+		assert.Equal(t, "[INFO] Test old record\n", string(actual))
+
+		// the new file was started at the rotation, so it is not rotated again after reopening
+		assert.NoError(t, log.Reopen())
+		log.Info("Test record after reopen")
+		_, err = os.Stat(agedpath + ".2")
+		assert.True(t, os.IsNotExist(err))
+	})
+
+	t.Run("Test Reopen", func(t *testing.T) {
+		err := log.SetFile(logpath, 0666)
+		if err != nil {
+			t.Fatalf("Failed switching log files: %s", err)
+		}
+		err = os.Rename(logpath, logpath+".moved")
+		if err != nil {
+			t.Fatalf("Failed to move log file: %s", err)
+		}
+		log.Info("Test before reopen")
+		err = log.Reopen()
+		assert.NoError(t, err)
+		log.Info("Test after reopen")
+
+		actual, err := getLastLineWithSeek(logpath + ".moved")
+		assert.NoError(t, err)
+		assert.Equal(t, "[INFO] Test before reopen\n", actual)
+		actual, err = getLastLineWithSeek(logpath)
+		assert.NoError(t, err)
+		assert.Equal(t, "[INFO] Test after reopen\n", actual)
+	})
+}
+
+func TestLogLevels(t *testing.T) {
+	sink := &testSink{}
+	log, err := logging.NewLogger(logging.TRACE, "console")
+	if err != nil {
+		t.Fatalf("Failed to create logger: %s", err)
+	}
+	log.SetSink(sink)
+	defer log.Destroy()
+
+	t.Run("Test TRACE and CRITICAL levels", func(t *testing.T) {
+		sink.records = nil
+		log.Trace("Test trace")
+		log.Critical("Test critical")
+		log.SetLogLevel(logging.CRITICAL)
+		log.Trace("Test trace filtered")
+		log.Error("Test error filtered")
+		log.Critical("Test critical 2")
+		log.SetLogLevel(logging.TRACE)
+
+		assert.Equal(t, 3, len(sink.records))
+		assert.Equal(t, logging.TRACE, sink.records[0].Level)
+		assert.Equal(t, "TRACE", sink.records[0].Level.String())
+		assert.Equal(t, logging.CRITICAL, sink.records[1].Level)
+		assert.Equal(t, "CRITICAL", sink.records[1].Level.String())
+		assert.Equal(t, "Test critical 2", sink.records[2].Message)
+	})
+
+	t.Run("Test ParseLogLevel", func(t *testing.T) {
+		cases := map[string]logging.LogLevel{
+			"trace":    logging.TRACE,
+			"DEBUG":    logging.DEBUG,
+			"Info":     logging.INFO,
+			"WARNING":  logging.WARN,
+			"warn":     logging.WARN,
+			"ERROR":    logging.ERROR,
+			"critical": logging.CRITICAL,
+			"FATAL":    logging.FATAL,
+		}
+		for name, expected := range cases {
+			level, err := logging.ParseLogLevel(name)
+			assert.NoError(t, err)
+			assert.Equal(t, expected, level)
+		}
+		_, err := logging.ParseLogLevel("LOUD")
+		assert.Error(t, err)
+	})
+
+	t.Run("Test named loggers", func(t *testing.T) {
+		sink.records = nil
+		amqp := log.Named("amqp10")
+		receiver := amqp.Named("receiver")
+		assert.Equal(t, amqp, log.Named("amqp10"))
+
+		err := log.SetLevelOf("amqp10", logging.ERROR)
+		assert.NoError(t, err)
+		assert.Error(t, log.SetLevelOf("unknown", logging.ERROR))
+
+		amqp.Info("Test named filtered")
+		amqp.Error("Test named")
+		receiver.Debug("Test named receiver")
+		log.Debug("Test root")
+
+		assert.Equal(t, 3, len(sink.records))
+		assert.Equal(t, logging.Metadata{"logger": "amqp10"}, sink.records[0].Metadata)
+		assert.Equal(t, logging.Metadata{"logger": "amqp10.receiver"}, sink.records[1].Metadata)
+		assert.Equal(t, "Test root", sink.records[2].Message)
+		assert.Equal(t, map[string]logging.LogLevel{"amqp10": logging.ERROR, "amqp10.receiver": logging.TRACE}, log.Levels())
+	})
+}
+
+func TestLoggerRateLimit(t *testing.T) {
+	sink := &testSink{}
+	log, err := logging.NewLogger(logging.DEBUG, "console")
+	if err != nil {
+		t.Fatalf("Failed to create logger: %s", err)
+	}
+	log.SetSink(sink)
+	defer log.Destroy()
+
+	t.Run("Test suppression with periodic summary", func(t *testing.T) {
+		log.SetRateLimit(100*time.Millisecond, 2)
+		for i := 0; i < 5; i++ {
+			log.Debug("Error while trying to read from unix socket.", logging.Metadata{"iteration": i})
+		}
+		log.Info("Test different message")
+		time.Sleep(150 * time.Millisecond)
+		log.Debug("Error while trying to read from unix socket.")
+		log.SetRateLimit(0, 0)
+
+		assert.Equal(t, 5, len(sink.records))
+		assert.Equal(t, logging.Metadata{"iteration": 0}, sink.records[0].Metadata)
+		assert.Equal(t, logging.Metadata{"iteration": 1}, sink.records[1].Metadata)
+		assert.Equal(t, "Test different message", sink.records[2].Message)
+		assert.Equal(t, logging.DEBUG, sink.records[3].Level)
+		assert.Equal(t, "suppressed 3 similar messages", sink.records[3].Message)
+		assert.Equal(t, logging.Metadata{"suppressed_message": "Error while trying to read from unix socket."}, sink.records[3].Metadata)
+		assert.Equal(t, "Error while trying to read from unix socket.", sink.records[4].Message)
+	})
+
+	t.Run("Test summary on disabling", func(t *testing.T) {
+		sink.records = nil
+		log.SetRateLimit(time.Hour, 1)
+		log.Warn("Test dedup")
+		log.Warn("Test dedup")
+		log.SetRateLimit(0, 0)
+		log.Warn("Test dedup")
+
+		assert.Equal(t, 3, len(sink.records))
+		assert.Equal(t, "Test dedup", sink.records[0].Message)
+		assert.Equal(t, "suppressed 1 similar messages", sink.records[1].Message)
+		assert.Equal(t, "Test dedup", sink.records[2].Message)
+	})
+}
+
+// blockingSink holds writes until released, so that the buffer of asynchronous logger fills up
+type blockingSink struct {
+	testSink
+	started chan struct{}
+	release chan struct{}
+}
+
+func (s *blockingSink) Write(record logging.Record) error {
+	select {
+	case s.started <- struct{}{}:
+	default:
+	}
+	<-s.release
+	return s.testSink.Write(record)
+}
+
+func TestLoggerAsync(t *testing.T) {
+	t.Run("Test buffered writes and Flush", func(t *testing.T) {
+		sink := &testSink{}
+		log, err := logging.NewLogger(logging.DEBUG, "console")
+		if err != nil {
+			t.Fatalf("Failed to create logger: %s", err)
+		}
+		log.SetSink(sink)
+		log.SetAsync(10, logging.OverflowBlock)
+		for i := 0; i < 100; i++ {
+			log.Info("Test async", logging.Metadata{"iteration": i})
+		}
+		log.Flush()
+
+		assert.Equal(t, 100, len(sink.records))
+		for i, record := range sink.records {
+			assert.Equal(t, logging.Metadata{"iteration": i}, record.Metadata)
+		}
+
+		log.Debug("Test drain on destroy")
+		log.Destroy()
+		assert.Equal(t, 101, len(sink.records))
+		assert.Equal(t, "Test drain on destroy", sink.records[100].Message)
+		assert.True(t, sink.closed)
+	})
+
+	policies := map[string]struct {
+		policy   logging.OverflowPolicy
+		messages []string
+	}{
+		"DropNewest": {logging.OverflowDropNewest, []string{"Test 0", "dropped 3 log records due to full buffer", "Test 1", "Test 2", "Test 6"}},
+		"DropOldest": {logging.OverflowDropOldest, []string{"Test 0", "dropped 3 log records due to full buffer", "Test 4", "Test 5", "Test 6"}},
+	}
+	for name, test := range policies {
+		t.Run("Test overflow policy "+name, func(t *testing.T) {
+			sink := &blockingSink{started: make(chan struct{}, 1), release: make(chan struct{})}
+			log, err := logging.NewLogger(logging.DEBUG, "console")
+			if err != nil {
+				t.Fatalf("Failed to create logger: %s", err)
+			}
+			log.SetSink(sink)
+			log.SetAsync(2, test.policy)
+
+			log.Info("Test 0")
+			<-sink.started
+			for i := 1; i < 6; i++ {
+				log.Info(fmt.Sprintf("Test %d", i))
+			}
+			close(sink.release)
+			log.Flush()
+			log.Info("Test 6")
+			log.Destroy()
+
+			messages := []string{}
+			for _, record := range sink.records {
+				messages = append(messages, record.Message)
+			}
+			assert.Equal(t, test.messages, messages)
+		})
+	}
+
+	t.Run("Test Flush with full buffer", func(t *testing.T) {
+		sink := &blockingSink{started: make(chan struct{}, 1), release: make(chan struct{})}
+		log, err := logging.NewLogger(logging.DEBUG, "console")
+		if err != nil {
+			t.Fatalf("Failed to create logger: %s", err)
+		}
+		log.SetSink(sink)
+		log.SetAsync(2, logging.OverflowDropOldest)
+
+		log.Info("Test 0")
+		<-sink.started
+		log.Info("Test 1")
+		flushed := make(chan struct{})
+		go func() {
+			log.Flush()
+			close(flushed)
+		}()
+		// wait for the flush marker to be queued, then push it out of the buffer
+		time.Sleep(50 * time.Millisecond)
+		log.Info("Test 2")
+		log.Info("Test 3")
+		select {
+		case <-flushed:
+			t.Fatal("Flush returned before the buffered records were written")
+		case <-time.After(100 * time.Millisecond):
+		}
+
+		close(sink.release)
+		<-flushed
+		log.Destroy()
+		if assert.NotEmpty(t, sink.records) {
+			assert.Equal(t, "Test 0", sink.records[0].Message)
+		}
+	})
+
+	t.Run("Test report of records dropped before shutdown", func(t *testing.T) {
+		sink := &testSink{}
+		log, err := logging.NewLogger(logging.DEBUG, "console")
+		if err != nil {
+			t.Fatalf("Failed to create logger: %s", err)
+		}
+		log.SetSink(sink)
+		log.SetAsync(1, logging.OverflowDropNewest)
+		total := 0
+		var wg sync.WaitGroup
+		for g := 0; g < 8; g++ {
+			wg.Add(1)
+			total += 500
+			go func() {
+				defer wg.Done()
+				for i := 0; i < 500; i++ {
+					log.Info("Test burst")
+				}
+			}()
+		}
+		wg.Wait()
+		log.Destroy()
+
+		// every record has to be either written or reported as dropped
+		written, dropped := 0, 0
+		for _, record := range sink.records {
+			if record.Message == "Test burst" {
+				written++
+				continue
+			}
+			var count int
+			if _, err := fmt.Sscanf(record.Message, "dropped %d log records due to full buffer", &count); err != nil {
+				t.Fatalf("Unexpected record: %s", record.Message)
+			}
+			dropped += count
+		}
+		assert.Equal(t, total, written+dropped)
+	})
+}
+
+func TestLoggerCaller(t *testing.T) {
+	sink := &testSink{}
+	log, err := logging.NewLogger(logging.DEBUG, "console")
+	if err != nil {
+		t.Fatalf("Failed to create logger: %s", err)
+	}
+	log.SetSink(sink)
+	defer log.Destroy()
+
+	t.Run("Test caller annotation", func(t *testing.T) {
+		sink.records = nil
+		log.Caller = true
+		log.Info("Test caller")
+		log.With(logging.Metadata{"foo": "bar"}).Warn("Test caller of child")
+		log.Caller = false
+		log.Info("Test without caller")
+
+		assert.Equal(t, 3, len(sink.records))
+		assert.Regexp(t, `^tests/logging_test\.go:[0-9]+$`, sink.records[0].Caller)
+		assert.Regexp(t, `TestLoggerCaller\.func1$`, sink.records[0].Function)
+		assert.Regexp(t, `^tests/logging_test\.go:[0-9]+$`, sink.records[1].Caller)
+		assert.Equal(t, "", sink.records[2].Caller)
+		assert.Equal(t, "", sink.records[0].Stack)
+	})
+
+	t.Run("Test stack capture", func(t *testing.T) {
+		sink.records = nil
+		log.Stack = true
+		log.Warn("Test no stack")
+		log.Error("Test stack")
+		log.Stack = false
+
+		assert.Equal(t, 2, len(sink.records))
+		assert.Equal(t, "", sink.records[0].Stack)
+		assert.Regexp(t, `^[^\n]*TestLoggerCaller\.func2\n\t[^\n]*tests/logging_test\.go:[0-9]+\n`, sink.records[1].Stack)
+	})
+
+	t.Run("Test formatted caller", func(t *testing.T) {
+		var buffer bytes.Buffer
+		log.SetSink(logging.NewWriterSink(&buffer))
+		log.Caller = true
+		log.Error("Test formatted caller")
+		log.Caller = false
+
+		assert.Regexp(t, `^\[ERROR\] Test formatted caller \(tests/logging_test\.go:[0-9]+ [^ ]*TestLoggerCaller\.func3\)\n$`, buffer.String())
+	})
+}
+
+func TestLevelControl(t *testing.T) {
+	sink := &testSink{}
+	log, err := logging.NewLogger(logging.INFO, "console")
+	if err != nil {
+		t.Fatalf("Failed to create logger: %s", err)
+	}
+	log.SetSink(sink)
+	defer log.Destroy()
+	amqp := log.Named("amqp10")
+
+	t.Run("Test HTTP handler", func(t *testing.T) {
+		server := httptest.NewServer(logging.NewLevelHandler(log))
+		defer server.Close()
+
+		response, err := http.Get(server.URL)
+		if err != nil {
+			t.Fatalf("Failed to get levels: %s", err)
+		}
+		body, _ := ioutil.ReadAll(response.Body)
+		response.Body.Close()
+		assert.JSONEq(t, `{"root": "INFO", "amqp10": "INFO"}`, string(body))
+
+		response, err = http.PostForm(server.URL, url.Values{"logger": {"amqp10"}, "level": {"debug"}})
+		if err != nil {
+			t.Fatalf("Failed to set level: %s", err)
+		}
+		body, _ = ioutil.ReadAll(response.Body)
+		response.Body.Close()
+		assert.Equal(t, http.StatusOK, response.StatusCode)
+		assert.JSONEq(t, `{"root": "INFO", "amqp10": "DEBUG"}`, string(body))
+
+		sink.records = nil
+		amqp.Debug("Test debug enabled")
+		assert.Equal(t, 1, len(sink.records))
+
+		response, err = http.PostForm(server.URL, url.Values{"logger": {"unknown"}, "level": {"debug"}})
+		if err != nil {
+			t.Fatalf("Failed to set level: %s", err)
+		}
+		response.Body.Close()
+		assert.Equal(t, http.StatusBadRequest, response.StatusCode)
+	})
+
+	t.Run("Test unix socket control", func(t *testing.T) {
+		tmpdir, err := ioutil.TempDir(".", "logging_test")
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer os.RemoveAll(tmpdir)
+		socket := path.Join(tmpdir, "control.sock")
+
+		control, err := logging.ServeLevelControl(log, socket)
+		if err != nil {
+			t.Fatalf("Failed to serve level control: %s", err)
+		}
+		defer control.Close()
+
+		conn, err := net.Dial("unix", socket)
+		if err != nil {
+			t.Fatalf("Failed to connect to level control: %s", err)
+		}
+		defer conn.Close()
+		reader := bufio.NewReader(conn)
+
+		fmt.Fprintln(conn, "set root WARN")
+		line, _ := reader.ReadString('\n')
+		assert.Equal(t, "OK\n", line)
+
+		fmt.Fprintln(conn, "set root LOUD")
+		line, _ = reader.ReadString('\n')
+		assert.Equal(t, "ERROR unknown log level: LOUD\n", line)
+
+		fmt.Fprintln(conn, "get")
+		lines := []string{}
+		for {
+			line, _ = reader.ReadString('\n')
+			if line == "\n" || line == "" {
+				break
+			}
+			lines = append(lines, line)
+		}
+		assert.Equal(t, []string{"amqp10 DEBUG\n", "root WARN\n"}, lines)
+	})
+}
+
+func TestLoggerStdLog(t *testing.T) {
+	sink := &testSink{}
+	logger, err := logging.NewLogger(logging.DEBUG, "console")
+	if err != nil {
+		t.Fatalf("Failed to create logger: %s", err)
+	}
+	logger.SetSink(sink)
+	defer logger.Destroy()
+
+	t.Run("Test redirect of standard log", func(t *testing.T) {
+		sink.records = nil
+		logger.Caller = true
+		log.SetFlags(log.LstdFlags)
+		restore := logging.RedirectStdLog(logger, logging.WARN)
+		log.Printf("Test standard %s", "log")
+		restore()
+		logger.Caller = false
+
+		assert.Equal(t, 1, len(sink.records))
+		assert.Equal(t, logging.WARN, sink.records[0].Level)
+		assert.Equal(t, "Test standard log", sink.records[0].Message)
+		assert.Regexp(t, `^tests/logging_test\.go:[0-9]+$`, sink.records[0].Caller)
+		assert.Equal(t, log.LstdFlags, log.Flags())
+	})
+
+	t.Run("Test standard logger", func(t *testing.T) {
+		sink.records = nil
+		logger.SetLogLevel(logging.INFO)
+		stdLogger := logging.NewStdLogger(logger, logging.DEBUG)
+		stdLogger.Println("Test filtered")
+		stdLogger = logging.NewStdLogger(logger.With(logging.Metadata{"library": "foo"}), logging.ERROR)
+		stdLogger.Println("Test standard logger")
+		logger.SetLogLevel(logging.DEBUG)
+
+		assert.Equal(t, 1, len(sink.records))
+		assert.Equal(t, logging.ERROR, sink.records[0].Level)
+		assert.Equal(t, "Test standard logger", sink.records[0].Message)
+		assert.Equal(t, logging.Metadata{"library": "foo"}, sink.records[0].Metadata)
+	})
 }
@@ -92,4 +92,33 @@ func TestStructsOperations(t *testing.T) {
 		assert.Equal(t, testMerge, map4)
 	})
 
+	t.Run("Test DeepMergeMaps", func(t *testing.T) {
+		testMerge := map[string]interface{}{
+			"map": map[string]interface{}{
+				"a":   "A",
+				"b":   "B",
+				"c":   map[string]interface{}{"1": 1, "2": "two"},
+				"d":   []interface{}{"d1", "d2"},
+				"foo": "bar",
+			},
+			"slice": []interface{}{"wubba", "lubba", "dub dub"},
+			"int":   int(1),
+		}
+		map5 := map[string]interface{}{
+			"map": map[string]interface{}{
+				"a": "A",
+				"b": "B",
+				"c": map[string]interface{}{"1": 1, "2": 2},
+				"d": []interface{}{"d1", "d2"},
+			},
+			"slice": []interface{}{"A", "B"},
+			"int":   int(1),
+		}
+		map6 := map[string]interface{}{"map": map[string]interface{}{"c": map[string]interface{}{"2": "two"}}}
+		merged := misc.DeepMergeMaps(map5, map3, map6)
+		assert.Equal(t, testMerge, merged)
+		// source maps stay untouched
+		assert.Equal(t, 2, map5["map"].(map[string]interface{})["c"].(map[string]interface{})["2"])
+	})
+
 }
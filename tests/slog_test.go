@@ -0,0 +1,58 @@
+//go:build go1.21
+// +build go1.21
+
+package tests
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"testing"
+
+	"github.com/infrawatch/apputils/logging"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSlogAdapter(t *testing.T) {
+	t.Run("Test slog handler", func(t *testing.T) {
+		sink := &testSink{}
+		logger, err := logging.NewLogger(logging.INFO, "console")
+		if err != nil {
+			t.Fatalf("Failed to create logger: %s", err)
+		}
+		logger.SetSink(sink)
+		defer logger.Destroy()
+
+		logger.Caller = true
+		slogger := slog.New(logging.NewSlogHandler(logger))
+		slogger.Debug("Test filtered")
+		slogger.With("component", "amqp10").WithGroup("conn").Warn("Test slog", "host", "localhost", slog.Group("tls", "enabled", true))
+		slogger.Log(context.Background(), slog.LevelError+4, "Test critical")
+
+		assert.Equal(t, 2, len(sink.records))
+		assert.Equal(t, logging.WARN, sink.records[0].Level)
+		assert.Equal(t, "Test slog", sink.records[0].Message)
+		assert.Equal(t, logging.Metadata{"component": "amqp10", "conn.host": "localhost", "conn.tls.enabled": true}, sink.records[0].Metadata)
+		assert.Regexp(t, `^tests/slog_test\.go:[0-9]+$`, sink.records[0].Caller)
+		assert.Equal(t, logging.CRITICAL, sink.records[1].Level)
+	})
+
+	t.Run("Test logger backed by slog handler", func(t *testing.T) {
+		var buffer bytes.Buffer
+		handler := slog.NewTextHandler(&buffer, &slog.HandlerOptions{Level: slog.LevelInfo})
+		logger := logging.NewSlogLogger(handler, logging.TRACE)
+		defer logger.Destroy()
+
+		logger.Debug("Test filtered by handler")
+		logger.Warn("Test slog sink", logging.Metadata{"foo": "bar", "count": 2})
+
+		assert.Equal(t, "level=WARN msg=\"Test slog sink\" count=2 foo=bar\n", buffer.String())
+	})
+
+	t.Run("Test level conversion", func(t *testing.T) {
+		for _, level := range []logging.LogLevel{logging.TRACE, logging.DEBUG, logging.INFO, logging.WARN, logging.ERROR, logging.CRITICAL} {
+			assert.Equal(t, level, logging.FromSlogLevel(logging.ToSlogLevel(level)))
+		}
+		assert.Equal(t, logging.INFO, logging.FromSlogLevel(slog.LevelInfo+2))
+	})
+}
@@ -0,0 +1,141 @@
+package tests
+
+import (
+	"fmt"
+	"io/ioutil"
+	"log"
+	"os"
+	"path"
+	"testing"
+
+	"github.com/infrawatch/apputils/config"
+	"github.com/infrawatch/apputils/logging"
+	"github.com/stretchr/testify/assert"
+)
+
+type InnerTOMLTestObject struct {
+	Type string `toml:"type"`
+	URL  string `toml:"url"`
+}
+
+type OuterTOMLTestObject struct {
+	Test        string                `toml:"test"`
+	Connections []InnerTOMLTestObject `toml:"data_sources"`
+}
+
+var TOMLConfigContent = `
+[Default]
+log_file = "/var/log/another.log"
+NoTag = "woot?"
+log_level = "DEBUG"
+port = 1234
+
+[Amqp1]
+float = 5.5
+
+[Amqp1.connections]
+test = "woobalooba"
+
+[[Amqp1.connections.data_sources]]
+type = "test1"
+url = "booyaka"
+
+[[Amqp1.connections.data_sources]]
+type = "test2"
+url = "foobar"
+`
+
+func TestTOMLConfigValues(t *testing.T) {
+	tmpdir, err := ioutil.TempDir(".", "config_test")
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer os.RemoveAll(tmpdir)
+	logpath := path.Join(tmpdir, "test.log")
+	filepath := path.Join(tmpdir, "test.toml")
+	if err := ioutil.WriteFile(filepath, []byte(TOMLConfigContent), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	log, err := logging.NewLogger(logging.DEBUG, logpath)
+	if err != nil {
+		fmt.Printf("Failed to open log file %s.\n", logpath)
+		os.Exit(2)
+	}
+	defer log.Destroy()
+
+	t.Run("Test parsed values from TOML configuration file", func(t *testing.T) {
+		conf, err := config.NewConfigForFile(filepath, JSONConfigMetadata, log)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var connections OuterTOMLTestObject
+		conf.(config.StructuredConfig).AddStructured("Amqp1", "Connections", `toml:"connections"`, connections)
+		err = conf.Parse(filepath)
+		if err != nil {
+			t.Fatal(err)
+		}
+		sections := conf.(*config.TOMLConfig).Sections
+		assert.Equal(t, "/var/log/another.log", sections["Default"].Options["LogFile"].GetString(), "Did not parse correctly")
+		assert.Equal(t, "DEBUG", sections["Default"].Options["LogLevel"].GetString(), "Did not parse correctly")
+		assert.Equal(t, true, sections["Default"].Options["AllowExec"].GetBool(), "Did not parse correctly")
+		assert.Equal(t, int64(1234), sections["Default"].Options["Port"].GetInt(), "Did not parse correctly")
+		assert.Equal(t, float64(5.5), sections["Amqp1"].Options["Float"].GetFloat(), "Did not parse correctly")
+
+		connTypedObj := sections["Amqp1"].Options["Connections"].GetStructured().(OuterTOMLTestObject)
+		assert.Equal(t, "woobalooba", connTypedObj.Test, "Did not parse correctly")
+		assert.Equal(t, []InnerTOMLTestObject{{"test1", "booyaka"}, {"test2", "foobar"}}, connTypedObj.Connections, "Did not parse correctly")
+	})
+
+	t.Run("Test flat value overridden by environment", func(t *testing.T) {
+		os.Setenv("TOML_OVERRIDE_PORT", "8")
+		defer os.Unsetenv("TOML_OVERRIDE_PORT")
+		metadata := map[string][]config.Parameter{
+			"Override": {{Name: "Port", Tag: `toml:"port"`, Default: 5}},
+		}
+		conf := config.NewTOMLConfig(metadata, log)
+		conf.SetEnvPrefix("toml")
+		err = conf.ParseBytes([]byte("[Override]\nport = 7\n"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		assert.Equal(t, int64(8), conf.Sections["Override"].Options["Port"].GetInt())
+	})
+
+	t.Run("Test structured values with fields without toml tags", func(t *testing.T) {
+		conf := config.NewTOMLConfig(map[string][]config.Parameter{}, log)
+		var connection UntaggedTestObject
+		conf.AddStructured("Amqp1", "Connection", "", connection)
+		err = conf.ParseBytes([]byte("[Amqp1.Connection]\nretries = 7\nSendTimeout = 9\n"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		opt := conf.Sections["Amqp1"].Options["Connection"]
+		assert.Equal(t, UntaggedTestObject{Retries: 7, SendTimeout: 9}, opt.GetStructured())
+		assert.Equal(t, "file", opt.Source())
+
+		// json tags are ignored by the TOML decoder, so the key is unknown and default is used
+		conf = config.NewTOMLConfig(map[string][]config.Parameter{}, log)
+		conf.AddStructured("Amqp1", "Connection", "", connection)
+		err = conf.ParseBytes([]byte("[Amqp1.Connection]\nsend_timeout = 9\n"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		assert.Equal(t, UntaggedTestObject{Retries: 3, SendTimeout: 5}, conf.Sections["Amqp1"].Options["Connection"].GetStructured())
+	})
+
+	t.Run("Test structured value overridden by environment", func(t *testing.T) {
+		os.Setenv("TOML_AMQP1_CONNECTIONS", `{test = "fromenv", data_sources = [{type = "env", url = "localhost"}]}`)
+		defer os.Unsetenv("TOML_AMQP1_CONNECTIONS")
+		conf := config.NewTOMLConfig(JSONConfigMetadata, log)
+		conf.SetEnvPrefix("toml")
+		var connections OuterTOMLTestObject
+		conf.AddStructured("Amqp1", "Connections", `toml:"connections"`, connections)
+		err = conf.Parse(filepath)
+		if err != nil {
+			t.Fatal(err)
+		}
+		connTypedObj := conf.Sections["Amqp1"].Options["Connections"].GetStructured().(OuterTOMLTestObject)
+		assert.Equal(t, OuterTOMLTestObject{"fromenv", []InnerTOMLTestObject{{"env", "localhost"}}}, connTypedObj)
+	})
+}
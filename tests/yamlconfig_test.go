@@ -0,0 +1,184 @@
+package tests
+
+import (
+	"fmt"
+	"io/ioutil"
+	"log"
+	"os"
+	"path"
+	"testing"
+
+	"github.com/infrawatch/apputils/config"
+	"github.com/infrawatch/apputils/logging"
+	"github.com/stretchr/testify/assert"
+)
+
+type InnerYAMLTestObject struct {
+	Type string `yaml:"type"`
+	URL  string `yaml:"url"`
+}
+
+type OuterYAMLTestObject struct {
+	Test        string                `yaml:"test"`
+	Connections []InnerYAMLTestObject `yaml:"data_sources"`
+}
+
+type UntaggedTestObject struct {
+	Retries     int `default:"3"`
+	SendTimeout int `json:"send_timeout" default:"5"`
+}
+
+var YAMLConfigContent = `
+Default:
+  log_file: /var/log/another.log
+  NoTag: woot?
+  log_level: DEBUG
+  port: 1234
+Amqp1:
+  float: 5.5
+  connections:
+    test: woobalooba
+    data_sources:
+      - type: test1
+        url: booyaka
+      - type: test2
+        url: foobar
+`
+
+func TestYAMLConfigValues(t *testing.T) {
+	tmpdir, err := ioutil.TempDir(".", "config_test")
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer os.RemoveAll(tmpdir)
+	logpath := path.Join(tmpdir, "test.log")
+	filepath := path.Join(tmpdir, "test.yaml")
+	if err := ioutil.WriteFile(filepath, []byte(YAMLConfigContent), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	log, err := logging.NewLogger(logging.DEBUG, logpath)
+	if err != nil {
+		fmt.Printf("Failed to open log file %s.\n", logpath)
+		os.Exit(2)
+	}
+	defer log.Destroy()
+
+	t.Run("Test parsed values from YAML configuration file", func(t *testing.T) {
+		conf := config.NewYAMLConfig(JSONConfigMetadata, log)
+		var connections OuterYAMLTestObject
+		conf.AddStructured("Amqp1", "Connections", `yaml:"connections"`, connections)
+		err = conf.Parse(filepath)
+		if err != nil {
+			t.Fatal(err)
+		}
+		assert.Equal(t, "/var/log/another.log", conf.Sections["Default"].Options["LogFile"].GetString(), "Did not parse correctly")
+		assert.Equal(t, "woot?", conf.Sections["Default"].Options["NoTag"].GetString(), "Did not parse correctly")
+		assert.Equal(t, "DEBUG", conf.Sections["Default"].Options["LogLevel"].GetString(), "Did not parse correctly")
+		assert.Equal(t, true, conf.Sections["Default"].Options["AllowExec"].GetBool(), "Did not parse correctly")
+		assert.Equal(t, int64(1234), conf.Sections["Default"].Options["Port"].GetInt(), "Did not parse correctly")
+		assert.Equal(t, float64(5.5), conf.Sections["Amqp1"].Options["Float"].GetFloat(), "Did not parse correctly")
+
+		connTypedObj := conf.Sections["Amqp1"].Options["Connections"].GetStructured().(OuterYAMLTestObject)
+		assert.Equal(t, "woobalooba", connTypedObj.Test, "Did not parse correctly")
+		assert.Equal(t, []InnerYAMLTestObject{{"test1", "booyaka"}, {"test2", "foobar"}}, connTypedObj.Connections, "Did not parse correctly")
+
+		opt, err := conf.GetOption("Amqp1.Connections.Test")
+		if assert.NoError(t, err) {
+			assert.Equal(t, "woobalooba", opt.GetString())
+		}
+	})
+
+	t.Run("Test flat value overridden by environment", func(t *testing.T) {
+		os.Setenv("YAML_OVERRIDE_PORT", "8")
+		defer os.Unsetenv("YAML_OVERRIDE_PORT")
+		metadata := map[string][]config.Parameter{
+			"Override": {{Name: "Port", Tag: `yaml:"port"`, Default: 5}},
+		}
+		conf := config.NewYAMLConfig(metadata, log)
+		conf.SetEnvPrefix("yaml")
+		err = conf.ParseBytes([]byte("Override:\n  port: 7\n"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		assert.Equal(t, int64(8), conf.Sections["Override"].Options["Port"].GetInt())
+	})
+
+	t.Run("Test structured value overridden by environment", func(t *testing.T) {
+		os.Setenv("YAML_AMQP1_CONNECTIONS", "{test: fromenv, data_sources: [{type: env, url: localhost}]}")
+		defer os.Unsetenv("YAML_AMQP1_CONNECTIONS")
+		conf := config.NewYAMLConfig(JSONConfigMetadata, log)
+		conf.SetEnvPrefix("yaml")
+		var connections OuterYAMLTestObject
+		conf.AddStructured("Amqp1", "Connections", `yaml:"connections"`, connections)
+		err = conf.Parse(filepath)
+		if err != nil {
+			t.Fatal(err)
+		}
+		connTypedObj := conf.Sections["Amqp1"].Options["Connections"].GetStructured().(OuterYAMLTestObject)
+		assert.Equal(t, OuterYAMLTestObject{"fromenv", []InnerYAMLTestObject{{"env", "localhost"}}}, connTypedObj)
+	})
+
+	t.Run("Test structured values with fields without yaml tags", func(t *testing.T) {
+		conf := config.NewYAMLConfig(map[string][]config.Parameter{}, log)
+		var connection UntaggedTestObject
+		conf.AddStructured("Amqp1", "Connection", "", connection)
+		err = conf.ParseBytes([]byte("Amqp1:\n  connection:\n    retries: 7\n    sendtimeout: 9\n"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		opt := conf.Sections["Amqp1"].Options["Connection"]
+		assert.Equal(t, UntaggedTestObject{Retries: 7, SendTimeout: 9}, opt.GetStructured())
+		assert.Equal(t, "file", opt.Source())
+
+		// json tags are ignored by the YAML decoder, so the key is unknown and default is used
+		conf = config.NewYAMLConfig(map[string][]config.Parameter{}, log)
+		conf.AddStructured("Amqp1", "Connection", "", connection)
+		err = conf.ParseBytes([]byte("Amqp1:\n  connection:\n    send_timeout: 9\n"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		assert.Equal(t, UntaggedTestObject{Retries: 3, SendTimeout: 5}, conf.Sections["Amqp1"].Options["Connection"].GetStructured())
+	})
+
+	t.Run("Test of strict mode", func(t *testing.T) {
+		conf := config.NewYAMLConfig(map[string][]config.Parameter{}, log)
+		conf.SetStrict(config.StrictError)
+		var connection UntaggedTestObject
+		conf.AddStructured("Amqp1", "Connection", "", connection)
+		assert.NoError(t, conf.ParseBytes([]byte("Amqp1:\n  connection:\n    retries: 7\n    sendtimeout: 9\n")))
+		// the YAML decoder matches keys case-sensitively and ignores json tags
+		err = conf.ParseBytes([]byte("Amqp1:\n  connection:\n    Retries: 7\n    send_timeout: 9\n"))
+		assert.EqualError(t, err, "2 errors in configuration: unknown field 'Retries' of parameter Amqp1.Connection (did you mean 'retries'?); unknown field 'send_timeout' of parameter Amqp1.Connection (did you mean 'sendtimeout'?)")
+	})
+
+	t.Run("Test invalid YAML", func(t *testing.T) {
+		conf := config.NewYAMLConfig(JSONConfigMetadata, log)
+		assert.Error(t, conf.ParseBytes([]byte("Default: [unclosed")))
+	})
+}
+
+func TestConfigFormatDetection(t *testing.T) {
+	log, err := logging.NewLogger(logging.DEBUG, "console")
+	if err != nil {
+		t.Fatalf("Failed to create logger: %s", err)
+	}
+	defer log.Destroy()
+
+	cases := map[string]interface{}{
+		"/etc/sensubility.conf": &config.INIConfig{},
+		"/etc/sg/config.INI":    &config.INIConfig{},
+		"config.json":           &config.JSONConfig{},
+		"configmap.yml":         &config.YAMLConfig{},
+		"configmap.yaml":        &config.YAMLConfig{},
+		"config.toml":           &config.TOMLConfig{},
+	}
+	for file, expected := range cases {
+		conf, err := config.NewConfigForFile(file, JSONConfigMetadata, log)
+		if assert.NoError(t, err) {
+			assert.IsType(t, expected, conf, file)
+		}
+	}
+	_, err = config.NewConfigForFile("config.xml", JSONConfigMetadata, log)
+	assert.Error(t, err)
+}